@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/attachments"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+// multipartOverhead is allowance for multipart headers and boundaries on top of attachment size
+const multipartOverhead = 64 << 10
+
+// maxMultipartMemory is size of parts kept in memory, larger files are buffered in temporary files
+const maxMultipartMemory = 1 << 20
+
+// uploadAttachment godoc
+// @Summary Upload attachment
+// @Description Upload file as multipart/form-data, content type is detected from file content
+// @Tags attachments
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param file formData file true "attached file"
+// @Success 201 {object} common.Attachment
+// @Header 201 {string} Location "/tasks/1/attachments/1"
+// @Failure 413 {string} string "file is too large"
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/attachments [post]
+func uploadAttachment(w http.ResponseWriter, httpReq *http.Request) {
+	limit := attachments.MaxSize + multipartOverhead
+	if httpReq.ContentLength > limit {
+		sendError(w, httpReq, common.NewPayloadTooLargeError("request body is too large"))
+		return
+	}
+	httpReq.Body = http.MaxBytesReader(w, httpReq.Body, limit)
+	if err := httpReq.ParseMultipartForm(maxMultipartMemory); err != nil && isBodyTooLarge(err) {
+		// chunked body has unknown length, so limit is detected only while reading
+		sendError(w, httpReq, common.NewPayloadTooLargeError("request body is too large"))
+		return
+	} else if err != nil {
+		http.Error(w, "invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer httpReq.MultipartForm.RemoveAll()
+	file, header, err := httpReq.FormFile("file")
+	if err != nil {
+		http.Error(w, "file field is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	var req = attachments.CreateRequest{
+		TaskId:  getTaskId(httpReq),
+		Name:    header.Filename,
+		Size:    header.Size,
+		Content: file,
+	}
+	req.SetCaller(getUserId(httpReq))
+	if err := common.Validate(req); err != nil {
+		sendError(w, httpReq, err)
+		return
+	}
+	ctx, cancel := handlerContext(httpReq)
+	defer cancel()
+	resp, err := resources.Handle(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		sendContextError(w, httpReq, ctx.Err(), err)
+		return
+	}
+	sendResponse(w, httpReq, resp, err)
+}
+
+// getAttachments godoc
+// @Summary Get attachments
+// @Description Get metadata of all task attachments
+// @Tags attachments
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} common.Attachment{}
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/attachments [get]
+func getAttachments(w http.ResponseWriter, httpReq *http.Request) {
+	var req = attachments.ReadCollectionRequest{
+		TaskId: getTaskId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// downloadAttachment godoc
+// @Summary Download attachment
+// @Description Download attachment content
+// @Tags attachments
+// @Produce  application/octet-stream
+// @Param task_id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {file} file "attachment content"
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/attachments/{attachment_id} [get]
+func downloadAttachment(w http.ResponseWriter, httpReq *http.Request) {
+	var req = attachments.ReadRequest{
+		TaskId:       getTaskId(httpReq),
+		AttachmentId: getAttachmentId(httpReq),
+	}
+	streamResponse(w, httpReq, &req)
+}
+
+// deleteAttachment godoc
+// @Summary Delete attachment
+// @Description Delete attachment with its content permanently
+// @Tags attachments
+// @Param task_id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/attachments/{attachment_id} [delete]
+func deleteAttachment(w http.ResponseWriter, httpReq *http.Request) {
+	var req = attachments.DeleteRequest{
+		TaskId:       getTaskId(httpReq),
+		AttachmentId: getAttachmentId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// isBodyTooLarge detects error of http.MaxBytesReader, which may be wrapped by multipart reader
+func isBodyTooLarge(err error) bool {
+	return strings.Contains(err.Error(), "request body too large")
+}
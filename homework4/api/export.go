@@ -0,0 +1,38 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"go.uber.org/zap"
+)
+
+// streamResponse writes response of request, which returns resources.Stream, as attachment
+func streamResponse(w http.ResponseWriter, httpReq *http.Request, req resources.Request) {
+	if authReq, ok := req.(resources.Authenticated); ok {
+		authReq.SetCaller(getUserId(httpReq))
+	}
+	ctx, cancel := handlerContext(httpReq)
+	defer cancel()
+	resp, err := resources.Handle(ctx, req)
+	if err != nil && ctx.Err() != nil {
+		sendContextError(w, httpReq, ctx.Err(), err)
+		return
+	} else if err != nil {
+		sendError(w, httpReq, err)
+		return
+	}
+	stream := resp.(resources.Stream)
+	w.Header().Set("Content-Type", stream.ContentType())
+	// content is never rendered by browser as page, even if it's HTML uploaded as attachment
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": stream.Filename(),
+	}))
+	// status is sent with the first written chunk, so later errors can only be logged
+	if err := stream.Stream(ctx, w); err != nil {
+		logger.Ctx(ctx).Error("error while streaming response", zap.Error(err))
+	}
+}
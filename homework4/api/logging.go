@@ -0,0 +1,57 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+// requestIdPattern restricts ids sent by clients or proxies, other ids are replaced with generated ones
+var requestIdPattern = regexp.MustCompile(`^[\w\-.:]{1,128}$`)
+
+// logRequest assigns id to request, unless it's already set by client or proxy, returns it in response,
+// attaches logger with request id to request context and writes access log line after request is handled
+func logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		start := time.Now()
+		requestId := httpReq.Header.Get(requestIdHeader)
+		if !requestIdPattern.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+		w.Header().Set(requestIdHeader, requestId)
+		trace.SpanFromContext(httpReq.Context()).SetAttributes(attribute.String("request_id", requestId))
+		ctx := logger.WithLogger(httpReq.Context(), logger.Zap.With(zap.String("request_id", requestId)))
+		ww := middleware.NewWrapResponseWriter(w, httpReq.ProtoMajor)
+		next.ServeHTTP(ww, httpReq.WithContext(ctx))
+		status := responseStatus(ww)
+		logger.Ctx(ctx).Info("request",
+			zap.String("method", httpReq.Method),
+			zap.String("path", httpReq.URL.Path),
+			zap.String("route", chi.RouteContext(httpReq.Context()).RoutePattern()),
+			zap.Int("status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", ww.BytesWritten()),
+		)
+	})
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// id is used only for correlation of log lines, so its uniqueness is not critical
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
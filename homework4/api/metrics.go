@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "gorello_http_requests_total",
+	Help: "Number of handled HTTP requests.",
+}, []string{"method", "route", "status"})
+
+var httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "gorello_http_request_duration_seconds",
+	Help:    "Latency of handled HTTP requests.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// measure counts requests and observes their latency, route pattern is used as label instead of path,
+// so number of series doesn't depend on ids in urls
+func measure(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, httpReq.ProtoMajor)
+		next.ServeHTTP(ww, httpReq)
+		route := chi.RouteContext(httpReq.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := responseStatus(ww)
+		labels := prometheus.Labels{"method": httpReq.Method, "route": route, "status": strconv.Itoa(status)}
+		httpRequests.With(labels).Inc()
+		httpRequestDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// responseStatus returns 200 if handler hasn't written anything, since net/http responds with it then
+func responseStatus(ww middleware.WrapResponseWriter) int {
+	if status := ww.Status(); status != 0 {
+		return status
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/users"
+)
+
+type contextKey string
+
+const userIdKey contextKey = "userId"
+
+// authenticate rejects requests without valid bearer token
+// and puts id of authenticated user into request context
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		token := strings.TrimPrefix(httpReq.Header.Get("Authorization"), "Bearer ")
+		ctx, cancel := handlerContext(httpReq)
+		userId, err := resources.Handle(ctx, users.AuthenticateRequest{Token: token})
+		cancel()
+		if err != nil {
+			sendError(w, httpReq, err)
+			return
+		}
+		ctx = context.WithValue(httpReq.Context(), userIdKey, userId.(common.Id))
+		next.ServeHTTP(w, httpReq.WithContext(ctx))
+	})
+}
+
+func getUserId(r *http.Request) common.Id {
+	userId, _ := r.Context().Value(userIdKey).(common.Id)
+	return userId
+}
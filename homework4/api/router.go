@@ -6,13 +6,24 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	_ "github.com/AndreyKlimchuk/golang-learning/homework4/docs"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/checklists"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/columns"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/comments"
 	_ "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/events"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/labels"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/members"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/projects"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/ranks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/search"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/tasks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/trash"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/users"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/webhooks"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // @title Gorello API
@@ -25,55 +36,164 @@ import (
 // @host friendly-drake-69422.herokuapp.com
 // @BasePath /api/v1
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name Authorization
+
 const BasePath = "/api/v1"
 
 func NewRouter() *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(traceRequest)
+	r.Use(logRequest)
+	r.Use(measure)
 	r.Use(middleware.Recoverer)
 
+	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+
 	r.Route(BasePath, func(r chi.Router) {
-		r.Route("/projects", func(r chi.Router) {
-			r.Post("/", createProject)
-			r.Get("/", getProjects)
-
-			r.Route("/{projectID:[\\d]+}", func(r chi.Router) {
-				r.Get("/", getProject)
-				r.Put("/", updateProject)
-				r.Delete("/", deleteProject)
-
-				r.Route("/columns", func(r chi.Router) {
-					r.Post("/", createColumn)
-					r.Get("/", getColumns)
-
-					r.Route("/{columnID:[\\d]+}", func(r chi.Router) {
-						r.Get("/", getColumn)
-						r.Put("/", updateColumn)
-						r.Delete("/", deleteColumn)
-
-						r.Put("/position", updateColumnPosition)
-						r.Post("/tasks", createTask)
+		r.Post("/users", createUser)
+		r.Post("/tokens", createToken)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authenticate)
+
+			r.Get("/users/{userID:[\\d]+}", getUser)
+			r.Get("/search", getSearchResults)
+			r.Get("/trash", getTrash)
+
+			r.Route("/projects", func(r chi.Router) {
+				r.Post("/", createProject)
+				r.Get("/", getProjects)
+				r.With(withTimeout(&longTimeout)).Post("/import", importProject)
+				r.With(withTimeout(&longTimeout)).Post("/import/trello", importTrelloBoard)
+
+				r.Route("/{projectID:[\\d]+}", func(r chi.Router) {
+					r.Get("/", getProject)
+					r.Put("/", updateProject)
+					r.Patch("/", patchProject)
+					r.Delete("/", deleteProject)
+					r.Post("/restore", restoreProject)
+					r.Post("/rebalance", rebalanceProject)
+					r.With(withTimeout(&longTimeout)).Get("/export", exportProject)
+					r.With(withTimeout(&longTimeout)).Get("/tasks.csv", getProjectTasksReport)
+					r.Get("/activity", getProjectActivity)
+					r.Get("/events", getProjectEvents)
+
+					r.Route("/members", func(r chi.Router) {
+						r.Get("/", getMembers)
+						r.Put("/{userID:[\\d]+}", updateMember)
+						r.Delete("/{userID:[\\d]+}", deleteMember)
+					})
+
+					r.Route("/labels", func(r chi.Router) {
+						r.Post("/", createLabel)
+						r.Get("/", getLabels)
+
+						r.Route("/{labelID:[\\d]+}", func(r chi.Router) {
+							r.Get("/", getLabel)
+							r.Put("/", updateLabel)
+							r.Delete("/", deleteLabel)
+						})
+					})
+
+					r.Route("/webhooks", func(r chi.Router) {
+						r.Post("/", createWebhook)
+						r.Get("/", getWebhooks)
+
+						r.Route("/{webhookID:[\\d]+}", func(r chi.Router) {
+							r.Get("/", getWebhook)
+							r.Put("/", updateWebhook)
+							r.Delete("/", deleteWebhook)
+							r.Get("/deliveries", getWebhookDeliveries)
+						})
+					})
+
+					r.Route("/columns", func(r chi.Router) {
+						r.Post("/", createColumn)
+						r.Get("/", getColumns)
+
+						r.Route("/{columnID:[\\d]+}", func(r chi.Router) {
+							r.Get("/", getColumn)
+							r.Put("/", updateColumn)
+							r.Patch("/", patchColumn)
+							r.Delete("/", deleteColumn)
+							r.Post("/restore", restoreColumn)
+
+							r.Put("/position", updateColumnPosition)
+							r.Post("/tasks", createTask)
+						})
 					})
 				})
 			})
-		})
 
-		r.Route("/tasks", func(r chi.Router) {
-			r.Route("/{taskID:[\\d]+}", func(r chi.Router) {
-				r.Get("/", getTask)
-				r.Put("/", updateTask)
-				r.Delete("/", deleteTask)
+			r.Route("/tasks", func(r chi.Router) {
+				r.Post("/batch", batchTasks)
+
+				r.Route("/{taskID:[\\d]+}", func(r chi.Router) {
+					r.Get("/", getTask)
+					r.Put("/", updateTask)
+					r.Patch("/", patchTask)
+					r.Delete("/", deleteTask)
+					r.Post("/restore", restoreTask)
+					r.Get("/activity", getTaskActivity)
+
+					r.Put("/position", updateTaskPosition)
+
+					r.Route("/labels", func(r chi.Router) {
+						r.Get("/", getTaskLabels)
+						r.Put("/{labelID:[\\d]+}", attachLabel)
+						r.Delete("/{labelID:[\\d]+}", detachLabel)
+					})
+
+					r.Route("/comments", func(r chi.Router) {
+						r.Post("/", createComment)
+						r.Get("/", getComments)
+
+						r.Route("/{commentID:[\\d]+}", func(r chi.Router) {
+							r.Get("/", getComment)
+							r.Put("/", updateComment)
+							r.Patch("/", patchComment)
+							r.Delete("/", deleteComment)
+							r.Post("/restore", restoreComment)
+							r.Get("/revisions", getCommentRevisions)
+						})
+					})
+
+					r.Route("/checklists", func(r chi.Router) {
+						r.Post("/", createChecklist)
+						r.Get("/", getChecklists)
+					})
+
+					r.Route("/attachments", func(r chi.Router) {
+						r.With(withTimeout(&longTimeout)).Post("/", uploadAttachment)
+						r.Get("/", getAttachments)
+
+						r.Route("/{attachmentID:[\\d]+}", func(r chi.Router) {
+							r.With(withTimeout(&longTimeout)).Get("/", downloadAttachment)
+							r.Delete("/", deleteAttachment)
+						})
+					})
+				})
+			})
 
-				r.Put("/position", updateTaskPosition)
+			r.Route("/checklists/{checklistID:[\\d]+}", func(r chi.Router) {
+				r.Get("/", getChecklist)
+				r.Put("/", updateChecklist)
+				r.Patch("/", patchChecklist)
+				r.Delete("/", deleteChecklist)
 
-				r.Route("/comments", func(r chi.Router) {
-					r.Post("/", createComment)
-					r.Get("/", getComments)
+				r.Route("/items", func(r chi.Router) {
+					r.Post("/", createChecklistItem)
+					r.Get("/", getChecklistItems)
 
-					r.Route("/{commentID:[\\d]+}", func(r chi.Router) {
-						r.Get("/", getComment)
-						r.Put("/", updateComment)
-						r.Delete("/", deleteComment)
+					r.Route("/{itemID:[\\d]+}", func(r chi.Router) {
+						r.Get("/", getChecklistItem)
+						r.Put("/", updateChecklistItem)
+						r.Patch("/", patchChecklistItem)
+						r.Delete("/", deleteChecklistItem)
+						r.Put("/position", updateChecklistItemPosition)
 					})
 				})
 			})
@@ -86,6 +206,92 @@ func NewRouter() *chi.Mux {
 	return r
 }
 
+// createUser godoc
+// @Summary Create user
+// @Description Register new user
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param body body common.Credentials true "request body"
+// @Success 201 {object} common.User
+// @Header 201 {string} Location "/users/1"
+// @Router /users [post]
+func createUser(w http.ResponseWriter, httpReq *http.Request) {
+	var req = users.CreateRequest{}
+	handleRequest(w, httpReq, &req)
+}
+
+// getUser godoc
+// @Summary Get user
+// @Description Get user
+// @Tags users
+// @Produce  json
+// @Param user_id path int true "User ID"
+// @Success 200 {object} common.User
+// @Security ApiKeyAuth
+// @Router /users/{user_id} [get]
+func getUser(w http.ResponseWriter, httpReq *http.Request) {
+	var req = users.ReadRequest{
+		TargetUserId: getTargetUserId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// createToken godoc
+// @Summary Create token
+// @Description Create access token, pass it in "Authorization: Bearer <token>" header
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param body body common.Credentials true "request body"
+// @Success 201 {object} common.Token
+// @Router /tokens [post]
+func createToken(w http.ResponseWriter, httpReq *http.Request) {
+	var req = users.CreateTokenRequest{}
+	handleRequest(w, httpReq, &req)
+}
+
+// getSearchResults godoc
+// @Summary Search
+// @Description Full-text search through names and descriptions of tasks and comments texts
+// @Description in projects where user is a member, results are sorted by relevance
+// @Tags search
+// @Produce  json
+// @Param q query string true "web search query, supports quotes, OR and - operators"
+// @Param project_id query int false "search only within project"
+// @Param limit query int false "max number of results" default(50)
+// @Success 200 {array} common.SearchHit{}
+// @Security ApiKeyAuth
+// @Router /search [get]
+func getSearchResults(w http.ResponseWriter, httpReq *http.Request) {
+	var req = search.Request{
+		Query:     getQueryParam(httpReq, "q"),
+		ProjectId: getIdQueryParam(httpReq, "project_id"),
+		Limit:     getLimit(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getTrash godoc
+// @Summary Get trash
+// @Description Get resources deleted in projects where user is a member, most recently deleted first.
+// @Description Resources deleted along with their project or task are restored with it and aren't listed.
+// @Description Trashed resources are purged permanently after retention period.
+// @Tags trash
+// @Produce  json
+// @Param project_id query int false "list only trash of project"
+// @Param limit query int false "max number of results" default(50)
+// @Success 200 {array} common.TrashItem{}
+// @Security ApiKeyAuth
+// @Router /trash [get]
+func getTrash(w http.ResponseWriter, httpReq *http.Request) {
+	var req = trash.ReadRequest{
+		ProjectId: getIdQueryParam(httpReq, "project_id"),
+		Limit:     getLimit(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // createProject godoc
 // @Summary Create project
 // @Description Create new project with single "default" column
@@ -95,6 +301,7 @@ func NewRouter() *chi.Mux {
 // @Param body body common.ProjectSettableFields true "request body"
 // @Success 201 {object} common.ProjectExpanded
 // @Header 201 {string} Location "/project/1"
+// @Security ApiKeyAuth
 // @Router /projects [post]
 func createProject(w http.ResponseWriter, httpReq *http.Request) {
 	var req = projects.CreateRequest{}
@@ -103,13 +310,23 @@ func createProject(w http.ResponseWriter, httpReq *http.Request) {
 
 // getProjects godoc
 // @Summary Get projects
-// @Description Get all projects
+// @Description Get page of projects, use next_cursor from response to get the next one
 // @Tags projects
 // @Produce  json
-// @Success 200 {array} common.Project{}
+// @Param limit query int false "max number of projects in page" default(50)
+// @Param cursor query string false "cursor from previous page"
+// @Param sort query string false "sort key" Enums(name, id, created) default(name)
+// @Param name query string false "filter by name substring"
+// @Success 200 {object} common.ProjectsPage
+// @Security ApiKeyAuth
 // @Router /projects [get]
 func getProjects(w http.ResponseWriter, httpReq *http.Request) {
-	var req = projects.ReadCollectionRequest{}
+	var req = projects.ReadCollectionRequest{
+		Limit:  getLimit(httpReq),
+		Cursor: getQueryParam(httpReq, "cursor"),
+		Sort:   getQueryParamOrDefault(httpReq, "sort", "name"),
+		Name:   getQueryParam(httpReq, "name"),
+	}
 	handleRequest(w, httpReq, &req)
 }
 
@@ -122,6 +339,7 @@ func getProjects(w http.ResponseWriter, httpReq *http.Request) {
 // @Param project_id path int true "Project ID"
 // @Param expanded query bool false "expand by sub-resources" default(false)
 // @Success 200 {object} common.ProjectExpanded
+// @Security ApiKeyAuth
 // @Router /projects/{project_id} [get]
 func getProject(w http.ResponseWriter, httpReq *http.Request) {
 	var req = projects.ReadRequest{
@@ -138,7 +356,9 @@ func getProject(w http.ResponseWriter, httpReq *http.Request) {
 // @Accept  json
 // @Param project_id path int true "Project ID"
 // @Param body body common.ProjectSettableFields true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /projects/{project_id} [put]
 func updateProject(w http.ResponseWriter, httpReq *http.Request) {
 	var req = projects.UpdateRequest{
@@ -147,12 +367,32 @@ func updateProject(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// patchProject godoc
+// @Summary Patch project
+// @Description Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields
+// @Tags projects
+// @Accept  json
+// @Param project_id path int true "Project ID"
+// @Param body body common.ProjectSettableFields true "merge patch"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id} [patch]
+func patchProject(w http.ResponseWriter, httpReq *http.Request) {
+	var req = projects.PatchRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // deleteProject godoc
 // @Summary Delete project
-// @Description Delete project and all sub-resources
+// @Description Move project and all sub-resources to trash
 // @Tags projects
 // @Param project_id path int true "Project ID"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /projects/{project_id} [delete]
 func deleteProject(w http.ResponseWriter, httpReq *http.Request) {
 	var req = projects.DeleteRequest{
@@ -161,6 +401,357 @@ func deleteProject(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// restoreProject godoc
+// @Summary Restore project
+// @Description Restore project from trash with all sub-resources deleted along with it
+// @Tags projects
+// @Param project_id path int true "Project ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/restore [post]
+func restoreProject(w http.ResponseWriter, httpReq *http.Request) {
+	var req = projects.RestoreRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// rebalanceProject godoc
+// @Summary Rebalance project
+// @Description Rewrite internal ranks of project columns and tasks to short evenly spaced values keeping their order.
+// @Description Ranks are rebalanced automatically when they grow too long, so it's only maintenance endpoint.
+// @Tags projects
+// @Param project_id path int true "Project ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/rebalance [post]
+func rebalanceProject(w http.ResponseWriter, httpReq *http.Request) {
+	var req = ranks.RebalanceRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// exportProject godoc
+// @Summary Export project
+// @Description Export project with labels, columns, tasks and comments as versioned JSON archive,
+// @Description which can be imported to another instance. Trashed resources aren't exported.
+// @Tags projects
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Success 200 {object} common.ProjectArchive
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/export [get]
+func exportProject(w http.ResponseWriter, httpReq *http.Request) {
+	var req = projects.ExportRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	streamResponse(w, httpReq, &req)
+}
+
+// getProjectTasksReport godoc
+// @Summary Get tasks report
+// @Description Get CSV report with row per task in board order: id, column name, position within column,
+// @Description name, description, number of comments, creation and last update time
+// @Tags projects
+// @Produce  text/csv
+// @Param project_id path int true "Project ID"
+// @Success 200 {string} string "CSV with header row"
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/tasks.csv [get]
+func getProjectTasksReport(w http.ResponseWriter, httpReq *http.Request) {
+	var req = projects.TasksReportRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	streamResponse(w, httpReq, &req)
+}
+
+// importProject godoc
+// @Summary Import project
+// @Description Create project from archive made by export with caller as owner. Resources get new ids,
+// @Description which are mapped to ids from archive in response. Assignees aren't imported.
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param body body common.ProjectArchive true "request body"
+// @Success 201 {object} common.ProjectImport
+// @Header 201 {string} Location "/project/1"
+// @Security ApiKeyAuth
+// @Router /projects/import [post]
+func importProject(w http.ResponseWriter, httpReq *http.Request) {
+	var req = projects.ImportRequest{}
+	handleRequest(w, httpReq, &req)
+}
+
+// importTrelloBoard godoc
+// @Summary Import Trello board
+// @Description Create project from Trello board JSON export with caller as owner. Open lists become columns
+// @Description and open cards become tasks in board order, labels, due dates and comments with their dates
+// @Description are imported too. Response maps Trello ids to ids of created resources.
+// @Tags projects
+// @Accept  json
+// @Produce  json
+// @Param body body projects.TrelloBoard true "Trello board export"
+// @Success 201 {object} projects.TrelloImport
+// @Header 201 {string} Location "/project/1"
+// @Security ApiKeyAuth
+// @Router /projects/import/trello [post]
+func importTrelloBoard(w http.ResponseWriter, httpReq *http.Request) {
+	var req = projects.TrelloImportRequest{}
+	handleRequest(w, httpReq, &req)
+}
+
+// getMembers godoc
+// @Summary Get members
+// @Description Get all members of project with their roles
+// @Tags members
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Success 200 {array} common.Member{}
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/members [get]
+func getMembers(w http.ResponseWriter, httpReq *http.Request) {
+	var req = members.ReadCollectionRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// updateMember godoc
+// @Summary Set member role
+// @Description Add user to project or change role of existing member, only owner is allowed to do it
+// @Tags members
+// @Accept  json
+// @Param project_id path int true "Project ID"
+// @Param user_id path int true "User ID"
+// @Param body body common.MemberSettableFields true "request body"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/members/{user_id} [put]
+func updateMember(w http.ResponseWriter, httpReq *http.Request) {
+	var req = members.UpdateRequest{
+		ProjectId: getProjectId(httpReq),
+		MemberId:  getTargetUserId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// deleteMember godoc
+// @Summary Delete member
+// @Description Remove user from project, only owner is allowed to do it
+// @Tags members
+// @Param project_id path int true "Project ID"
+// @Param user_id path int true "User ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/members/{user_id} [delete]
+func deleteMember(w http.ResponseWriter, httpReq *http.Request) {
+	var req = members.DeleteRequest{
+		ProjectId: getProjectId(httpReq),
+		MemberId:  getTargetUserId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// createLabel godoc
+// @Summary Create label
+// @Description Create new label in project's labels catalog
+// @Tags labels
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Param body body common.LabelSettableFields true "request body"
+// @Success 201 {object} common.Label
+// @Header 201 {string} Location "/project/1/labels/1"
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/labels [post]
+func createLabel(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.CreateRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getLabels godoc
+// @Summary Get labels
+// @Description Get all labels within project
+// @Tags labels
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Success 200 {array} common.Label{}
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/labels [get]
+func getLabels(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.ReadCollectionRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getLabel godoc
+// @Summary Get label
+// @Description Get label
+// @Tags labels
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} common.Label
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/labels/{label_id} [get]
+func getLabel(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.ReadRequest{
+		ProjectId: getProjectId(httpReq),
+		LabelId:   getLabelId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// updateLabel godoc
+// @Summary Update label
+// @Description Update label
+// @Tags labels
+// @Accept  json
+// @Param project_id path int true "Project ID"
+// @Param label_id path int true "Label ID"
+// @Param body body common.LabelSettableFields true "request body"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/labels/{label_id} [put]
+func updateLabel(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.UpdateRequest{
+		ProjectId: getProjectId(httpReq),
+		LabelId:   getLabelId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// deleteLabel godoc
+// @Summary Delete label
+// @Description Delete label and detach it from all tasks
+// @Tags labels
+// @Param project_id path int true "Project ID"
+// @Param label_id path int true "Label ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/labels/{label_id} [delete]
+func deleteLabel(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.DeleteRequest{
+		ProjectId: getProjectId(httpReq),
+		LabelId:   getLabelId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// createWebhook godoc
+// @Summary Create webhook
+// @Description Subscribe URL to project events, deliveries are signed with secret, which is generated if omitted
+// @Description and returned only in this response
+// @Tags webhooks
+// @Accept  json
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Param body body common.WebhookSettableFields true "request body"
+// @Success 201 {object} common.Webhook
+// @Header 201 {string} Location "/projects/1/webhooks/1"
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/webhooks [post]
+func createWebhook(w http.ResponseWriter, httpReq *http.Request) {
+	var req = webhooks.CreateRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getWebhooks godoc
+// @Summary Get webhooks
+// @Description Get all webhooks of project without secrets
+// @Tags webhooks
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Success 200 {array} common.Webhook{}
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/webhooks [get]
+func getWebhooks(w http.ResponseWriter, httpReq *http.Request) {
+	var req = webhooks.ReadCollectionRequest{
+		ProjectId: getProjectId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getWebhook godoc
+// @Summary Get webhook
+// @Description Get webhook without secret
+// @Tags webhooks
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Param webhook_id path int true "Webhook ID"
+// @Success 200 {object} common.Webhook
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/webhooks/{webhook_id} [get]
+func getWebhook(w http.ResponseWriter, httpReq *http.Request) {
+	var req = webhooks.ReadRequest{
+		ProjectId: getProjectId(httpReq),
+		WebhookId: getWebhookId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// updateWebhook godoc
+// @Summary Update webhook
+// @Description Update webhook, the current secret is kept if it's omitted
+// @Tags webhooks
+// @Accept  json
+// @Param project_id path int true "Project ID"
+// @Param webhook_id path int true "Webhook ID"
+// @Param body body common.WebhookSettableFields true "request body"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/webhooks/{webhook_id} [put]
+func updateWebhook(w http.ResponseWriter, httpReq *http.Request) {
+	var req = webhooks.UpdateRequest{
+		ProjectId: getProjectId(httpReq),
+		WebhookId: getWebhookId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// deleteWebhook godoc
+// @Summary Delete webhook
+// @Description Delete webhook with its deliveries, pending deliveries aren't sent
+// @Tags webhooks
+// @Param project_id path int true "Project ID"
+// @Param webhook_id path int true "Webhook ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/webhooks/{webhook_id} [delete]
+func deleteWebhook(w http.ResponseWriter, httpReq *http.Request) {
+	var req = webhooks.DeleteRequest{
+		ProjectId: getProjectId(httpReq),
+		WebhookId: getWebhookId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getWebhookDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Get deliveries of webhook newest first with their status and result of the last attempt
+// @Tags webhooks
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Param webhook_id path int true "Webhook ID"
+// @Param limit query int false "max number of results" default(50)
+// @Success 200 {array} common.WebhookDelivery{}
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/webhooks/{webhook_id}/deliveries [get]
+func getWebhookDeliveries(w http.ResponseWriter, httpReq *http.Request) {
+	var req = webhooks.ReadDeliveriesRequest{
+		ProjectId: getProjectId(httpReq),
+		WebhookId: getWebhookId(httpReq),
+		Limit:     getLimit(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // createColumn godoc
 // @Description Create new column
 // @Summary Create column
@@ -171,6 +762,7 @@ func deleteProject(w http.ResponseWriter, httpReq *http.Request) {
 // @Param body body common.ColumnSettableFields true "request body"
 // @Success 201 {object} common.Column
 // @Header 201 {string} Location "/project/1/columns/1"
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns [post]
 func createColumn(w http.ResponseWriter, httpReq *http.Request) {
 	var req = columns.CreateRequest{
@@ -186,6 +778,7 @@ func createColumn(w http.ResponseWriter, httpReq *http.Request) {
 // @Produce  json
 // @Param project_id path int true "Project ID"
 // @Success 200 {array} common.Column{}
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns [get]
 func getColumns(w http.ResponseWriter, httpReq *http.Request) {
 	var req = columns.ReadCollectionRequest{
@@ -202,6 +795,7 @@ func getColumns(w http.ResponseWriter, httpReq *http.Request) {
 // @Param project_id path int true "Project ID"
 // @Param column_id path int true "Column ID"
 // @Success 200 {object} common.Column
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns/{column_id} [get]
 func getColumn(w http.ResponseWriter, httpReq *http.Request) {
 	var req = columns.ReadRequest{
@@ -219,7 +813,9 @@ func getColumn(w http.ResponseWriter, httpReq *http.Request) {
 // @Param project_id path int true "Project ID"
 // @Param column_id path int true "Column ID"
 // @Param body body common.ColumnSettableFields true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns/{column_id} [put]
 func updateColumn(w http.ResponseWriter, httpReq *http.Request) {
 	var req = columns.UpdateRequest{
@@ -229,6 +825,26 @@ func updateColumn(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// patchColumn godoc
+// @Summary Patch column
+// @Description Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields
+// @Tags columns
+// @Accept  json
+// @Param project_id path int true "Project ID"
+// @Param column_id path int true "Column ID"
+// @Param body body common.ColumnSettableFields true "merge patch"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/columns/{column_id} [patch]
+func patchColumn(w http.ResponseWriter, httpReq *http.Request) {
+	var req = columns.PatchRequest{
+		ProjectId: getProjectId(httpReq),
+		ColumnId:  getColumnId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // updateColumnPosition godoc
 // @Summary Update column's position
 // @Description Place column after column specified by after_column_id
@@ -239,6 +855,7 @@ func updateColumn(w http.ResponseWriter, httpReq *http.Request) {
 // @Param column_id path int true "Column ID"
 // @Param body body columns.UpdatePositionRequestBody true "request body"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns/{column_id}/position [put]
 func updateColumnPosition(w http.ResponseWriter, httpReq *http.Request) {
 	var req = columns.UpdatePositionRequest{
@@ -250,11 +867,13 @@ func updateColumnPosition(w http.ResponseWriter, httpReq *http.Request) {
 
 // deleteColumn godoc
 // @Summary Delete column
-// @Description Delete column and move all tasks to the neighbor
+// @Description Move column to trash and move all its tasks to the neighbor
 // @Tags columns
 // @Param project_id path int true "Project ID"
 // @Param column_id path int true "Column ID"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns/{column_id} [delete]
 func deleteColumn(w http.ResponseWriter, httpReq *http.Request) {
 	var req = columns.DeleteRequest{
@@ -264,6 +883,23 @@ func deleteColumn(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// restoreColumn godoc
+// @Summary Restore column
+// @Description Restore empty column from trash to its former position, tasks moved out of it stay in the neighbor
+// @Tags columns
+// @Param project_id path int true "Project ID"
+// @Param column_id path int true "Column ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/columns/{column_id}/restore [post]
+func restoreColumn(w http.ResponseWriter, httpReq *http.Request) {
+	var req = columns.RestoreRequest{
+		ProjectId: getProjectId(httpReq),
+		ColumnId:  getColumnId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // createTask godoc
 // @Summary Create task
 // @Description Create new task
@@ -275,6 +911,7 @@ func deleteColumn(w http.ResponseWriter, httpReq *http.Request) {
 // @Param body body common.TaskSettableFields true "request body"
 // @Success 201 {object} common.Task
 // @Header 201 {string} Location "/tasks/1"
+// @Security ApiKeyAuth
 // @Router /projects/{project_id}/columns/{column_id}/tasks [post]
 func createTask(w http.ResponseWriter, httpReq *http.Request) {
 	var req = tasks.CreateRequest{
@@ -292,6 +929,7 @@ func createTask(w http.ResponseWriter, httpReq *http.Request) {
 // @Param task_id path int true "Task ID"
 // @Param expanded query bool false "expand by sub-resources" default(false)
 // @Success 200 {object} common.TaskExpanded
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id} [get]
 func getTask(w http.ResponseWriter, httpReq *http.Request) {
 	var req = tasks.ReadRequest{
@@ -308,7 +946,9 @@ func getTask(w http.ResponseWriter, httpReq *http.Request) {
 // @Accept  json
 // @Param task_id path int true "Task ID"
 // @Param body body common.TaskSettableFields true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id} [put]
 func updateTask(w http.ResponseWriter, httpReq *http.Request) {
 	var req = tasks.UpdateRequest{
@@ -317,6 +957,24 @@ func updateTask(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// patchTask godoc
+// @Summary Patch task
+// @Description Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields
+// @Tags tasks
+// @Accept  json
+// @Param task_id path int true "Task ID"
+// @Param body body common.TaskSettableFields true "merge patch"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id} [patch]
+func patchTask(w http.ResponseWriter, httpReq *http.Request) {
+	var req = tasks.PatchRequest{
+		TaskId: getTaskId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // updateTaskPosition godoc
 // @Summary Update task's position
 // @Description Place task after task specified by after_task_id
@@ -325,7 +983,9 @@ func updateTask(w http.ResponseWriter, httpReq *http.Request) {
 // @Accept  json
 // @Param task_id path int true "Task ID"
 // @Param body body tasks.UpdatePositionRequestBody true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id}/position [put]
 func updateTaskPosition(w http.ResponseWriter, httpReq *http.Request) {
 	var req = tasks.UpdatePositionRequest{
@@ -336,10 +996,12 @@ func updateTaskPosition(w http.ResponseWriter, httpReq *http.Request) {
 
 // deleteTask godoc
 // @Summary Delete task
-// @Description Delete task with all sub-resources
+// @Description Move task with its comments and attachments to trash
 // @Tags tasks
 // @Param task_id path int true "Task ID"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id} [delete]
 func deleteTask(w http.ResponseWriter, httpReq *http.Request) {
 	var req = tasks.DeleteRequest{
@@ -348,6 +1010,149 @@ func deleteTask(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// batchTasks godoc
+// @Summary Batch task operations
+// @Description Move, update or delete multiple tasks atomically, operations are applied in order
+// @Description within single transaction. If any operation fails, none is applied and error message
+// @Description is prefixed with index of failed operation.
+// @Tags tasks
+// @Accept  json
+// @Produce  json
+// @Param body body tasks.BatchRequest true "request body"
+// @Success 200 {array} tasks.BatchResult{}
+// @Security ApiKeyAuth
+// @Router /tasks/batch [post]
+func batchTasks(w http.ResponseWriter, httpReq *http.Request) {
+	var req = tasks.BatchRequest{}
+	handleRequestWith(w, httpReq, &req, sendOKResponse)
+}
+
+// restoreTask godoc
+// @Summary Restore task
+// @Description Restore task from trash with comments deleted along with it
+// @Tags tasks
+// @Param task_id path int true "Task ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/restore [post]
+func restoreTask(w http.ResponseWriter, httpReq *http.Request) {
+	var req = tasks.RestoreRequest{
+		TaskId: getTaskId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getProjectActivity godoc
+// @Summary Get project activity
+// @Description Get page of project activity feed, newest changes first, use next_cursor from response to get the next one
+// @Tags activity
+// @Produce  json
+// @Param project_id path int true "Project ID"
+// @Param limit query int false "max number of entries in page" default(50)
+// @Param cursor query string false "cursor from previous page"
+// @Success 200 {object} common.ActivityPage
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/activity [get]
+func getProjectActivity(w http.ResponseWriter, httpReq *http.Request) {
+	var req = activity.ReadProjectFeedRequest{
+		ProjectId: getProjectId(httpReq),
+		Limit:     getLimit(httpReq),
+		Cursor:    getQueryParam(httpReq, "cursor"),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getProjectEvents godoc
+// @Summary Stream project events
+// @Description Stream of server-sent events, one per committed project change. Event id is activity entry id,
+// @Description event name is <resource_type>.<action> (e.g. task.move) and data is activity entry.
+// @Description Events missed since Last-Event-ID are replayed on reconnect,
+// @Description "reset" event is sent when there are too many of them and board should be reloaded.
+// @Tags activity
+// @Produce  text/event-stream
+// @Param project_id path int true "Project ID"
+// @Param Last-Event-ID header int false "id of the last received event"
+// @Success 200 {object} common.Activity
+// @Security ApiKeyAuth
+// @Router /projects/{project_id}/events [get]
+func getProjectEvents(w http.ResponseWriter, httpReq *http.Request) {
+	var req = events.SubscribeRequest{
+		ProjectId:   getProjectId(httpReq),
+		LastEventId: getLastEventId(httpReq),
+	}
+	streamEvents(w, httpReq, &req)
+}
+
+// getTaskActivity godoc
+// @Summary Get task activity
+// @Description Get page of task activity feed including changes of task comments and labels, newest changes first
+// @Tags activity
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param limit query int false "max number of entries in page" default(50)
+// @Param cursor query string false "cursor from previous page"
+// @Success 200 {object} common.ActivityPage
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/activity [get]
+func getTaskActivity(w http.ResponseWriter, httpReq *http.Request) {
+	var req = activity.ReadTaskFeedRequest{
+		TaskId: getTaskId(httpReq),
+		Limit:  getLimit(httpReq),
+		Cursor: getQueryParam(httpReq, "cursor"),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getTaskLabels godoc
+// @Summary Get task labels
+// @Description Get all labels attached to task
+// @Tags labels
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} common.Label{}
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/labels [get]
+func getTaskLabels(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.ReadTaskLabelsRequest{
+		TaskId: getTaskId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// attachLabel godoc
+// @Summary Attach label
+// @Description Attach label from project's catalog to task
+// @Tags labels
+// @Param task_id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/labels/{label_id} [put]
+func attachLabel(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.AttachRequest{
+		TaskId:  getTaskId(httpReq),
+		LabelId: getLabelId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// detachLabel godoc
+// @Summary Detach label
+// @Description Detach label from task
+// @Tags labels
+// @Param task_id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/labels/{label_id} [delete]
+func detachLabel(w http.ResponseWriter, httpReq *http.Request) {
+	var req = labels.DetachRequest{
+		TaskId:  getTaskId(httpReq),
+		LabelId: getLabelId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // createComment godoc
 // @Summary Create comment
 // @Description Create new comment
@@ -358,6 +1163,7 @@ func deleteTask(w http.ResponseWriter, httpReq *http.Request) {
 // @Param body body common.CommentSettableFields true "request body"
 // @Success 201 {object} common.Comment
 // @Header 201 {string} Location "/tasks/1/comments/1"
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id}/comments [post]
 func createComment(w http.ResponseWriter, httpReq *http.Request) {
 	var req = comments.CreateRequest{
@@ -373,6 +1179,7 @@ func createComment(w http.ResponseWriter, httpReq *http.Request) {
 // @Produce  json
 // @Param task_id path int true "Task ID"
 // @Success 200 {array} common.Comment{}
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id}/comments [get]
 func getComments(w http.ResponseWriter, httpReq *http.Request) {
 	var req = comments.ReadCollectionRequest{
@@ -389,6 +1196,7 @@ func getComments(w http.ResponseWriter, httpReq *http.Request) {
 // @Param task_id path int true "Task ID"
 // @Param comment_id path int true "Comment ID"
 // @Success 200 {object} common.Comment
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id}/comments/{comment_id} [get]
 func getComment(w http.ResponseWriter, httpReq *http.Request) {
 	var req = comments.ReadRequest{
@@ -400,13 +1208,15 @@ func getComment(w http.ResponseWriter, httpReq *http.Request) {
 
 // updateComment godoc
 // @Summary Update comment
-// @Description Update comment
+// @Description Update comment, its previous text is kept in revisions
 // @Tags comments
 // @Accept  json
 // @Param task_id path int true "Task ID"
 // @Param comment_id path int true "Comment ID"
 // @Param body body common.CommentSettableFields true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id}/comments/{comment_id} [put]
 func updateComment(w http.ResponseWriter, httpReq *http.Request) {
 	var req = comments.UpdateRequest{
@@ -416,13 +1226,35 @@ func updateComment(w http.ResponseWriter, httpReq *http.Request) {
 	handleRequest(w, httpReq, &req)
 }
 
+// patchComment godoc
+// @Summary Patch comment
+// @Description Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields
+// @Tags comments
+// @Accept  json
+// @Param task_id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Param body body common.CommentSettableFields true "merge patch"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/comments/{comment_id} [patch]
+func patchComment(w http.ResponseWriter, httpReq *http.Request) {
+	var req = comments.PatchRequest{
+		TaskId:    getTaskId(httpReq),
+		CommentId: getCommentId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
 // deleteComment godoc
 // @Summary Delete comment
-// @Description Delete comment
+// @Description Move comment to trash
 // @Tags comments
 // @Param task_id path int true "Task ID"
 // @Param comment_id path int true "Comment ID"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
 // @Success 204
+// @Security ApiKeyAuth
 // @Router /tasks/{task_id}/comments/{comment_id} [delete]
 func deleteComment(w http.ResponseWriter, httpReq *http.Request) {
 	var req = comments.DeleteRequest{
@@ -431,3 +1263,271 @@ func deleteComment(w http.ResponseWriter, httpReq *http.Request) {
 	}
 	handleRequest(w, httpReq, &req)
 }
+
+// getCommentRevisions godoc
+// @Summary Get comment revisions
+// @Description Get previous texts of comment newest first
+// @Tags comments
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {array} common.CommentRevision{}
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/comments/{comment_id}/revisions [get]
+func getCommentRevisions(w http.ResponseWriter, httpReq *http.Request) {
+	var req = comments.ReadRevisionsRequest{
+		TaskId:    getTaskId(httpReq),
+		CommentId: getCommentId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// restoreComment godoc
+// @Summary Restore comment
+// @Description Restore comment from trash
+// @Tags comments
+// @Param task_id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/comments/{comment_id}/restore [post]
+func restoreComment(w http.ResponseWriter, httpReq *http.Request) {
+	var req = comments.RestoreRequest{
+		TaskId:    getTaskId(httpReq),
+		CommentId: getCommentId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// createChecklist godoc
+// @Summary Create checklist
+// @Description Create new empty checklist
+// @Tags checklists
+// @Accept  json
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Param body body common.ChecklistSettableFields true "request body"
+// @Success 201 {object} common.Checklist
+// @Header 201 {string} Location "/checklists/1"
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/checklists [post]
+func createChecklist(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.CreateRequest{
+		TaskId: getTaskId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getChecklists godoc
+// @Summary Get checklists
+// @Description Get all checklists of task with their items in order of creation
+// @Tags checklists
+// @Produce  json
+// @Param task_id path int true "Task ID"
+// @Success 200 {array} common.Checklist{}
+// @Security ApiKeyAuth
+// @Router /tasks/{task_id}/checklists [get]
+func getChecklists(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.ReadCollectionRequest{
+		TaskId: getTaskId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getChecklist godoc
+// @Summary Get checklist
+// @Description Get checklist with items
+// @Tags checklists
+// @Produce  json
+// @Param checklist_id path int true "Checklist ID"
+// @Success 200 {object} common.Checklist
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id} [get]
+func getChecklist(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.ReadRequest{
+		ChecklistId: getChecklistId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// updateChecklist godoc
+// @Summary Update checklist
+// @Description Update checklist
+// @Tags checklists
+// @Accept  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param body body common.ChecklistSettableFields true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id} [put]
+func updateChecklist(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.UpdateRequest{
+		ChecklistId: getChecklistId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// patchChecklist godoc
+// @Summary Patch checklist
+// @Description Update only fields supplied in JSON merge patch (RFC 7396)
+// @Tags checklists
+// @Accept  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param body body common.ChecklistSettableFields true "merge patch"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id} [patch]
+func patchChecklist(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.PatchRequest{
+		ChecklistId: getChecklistId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// deleteChecklist godoc
+// @Summary Delete checklist
+// @Description Delete checklist with items permanently
+// @Tags checklists
+// @Param checklist_id path int true "Checklist ID"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id} [delete]
+func deleteChecklist(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.DeleteRequest{
+		ChecklistId: getChecklistId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// createChecklistItem godoc
+// @Summary Create checklist item
+// @Description Create new item at the bottom of checklist
+// @Tags checklists
+// @Accept  json
+// @Produce  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param body body common.ChecklistItemSettableFields true "request body"
+// @Success 201 {object} common.ChecklistItem
+// @Header 201 {string} Location "/checklists/1/items/1"
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items [post]
+func createChecklistItem(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.CreateItemRequest{
+		ChecklistId: getChecklistId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getChecklistItems godoc
+// @Summary Get checklist items
+// @Description Get all items of checklist in order
+// @Tags checklists
+// @Produce  json
+// @Param checklist_id path int true "Checklist ID"
+// @Success 200 {array} common.ChecklistItem{}
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items [get]
+func getChecklistItems(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.ReadItemsRequest{
+		ChecklistId: getChecklistId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// getChecklistItem godoc
+// @Summary Get checklist item
+// @Description Get checklist item
+// @Tags checklists
+// @Produce  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param item_id path int true "Item ID"
+// @Success 200 {object} common.ChecklistItem
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items/{item_id} [get]
+func getChecklistItem(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.ReadItemRequest{
+		ChecklistId: getChecklistId(httpReq),
+		ItemId:      getItemId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// updateChecklistItem godoc
+// @Summary Update checklist item
+// @Description Update checklist item
+// @Tags checklists
+// @Accept  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param item_id path int true "Item ID"
+// @Param body body common.ChecklistItemSettableFields true "request body"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items/{item_id} [put]
+func updateChecklistItem(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.UpdateItemRequest{
+		ChecklistId: getChecklistId(httpReq),
+		ItemId:      getItemId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// patchChecklistItem godoc
+// @Summary Patch checklist item
+// @Description Update only fields supplied in JSON merge patch (RFC 7396), e.g. {"done": true} completes item
+// @Tags checklists
+// @Accept  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param item_id path int true "Item ID"
+// @Param body body common.ChecklistItemSettableFields true "merge patch"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items/{item_id} [patch]
+func patchChecklistItem(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.PatchItemRequest{
+		ChecklistId: getChecklistId(httpReq),
+		ItemId:      getItemId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// deleteChecklistItem godoc
+// @Summary Delete checklist item
+// @Description Delete checklist item permanently
+// @Tags checklists
+// @Param checklist_id path int true "Checklist ID"
+// @Param item_id path int true "Item ID"
+// @Param If-Match header string false "ETag of resource, 412 is returned if it doesn't match"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items/{item_id} [delete]
+func deleteChecklistItem(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.DeleteItemRequest{
+		ChecklistId: getChecklistId(httpReq),
+		ItemId:      getItemId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
+
+// updateChecklistItemPosition godoc
+// @Summary Update checklist item's position
+// @Description Place item after item specified by after_item_id if it is grater than 0, otherwise at the top
+// @Tags checklists
+// @Accept  json
+// @Param checklist_id path int true "Checklist ID"
+// @Param item_id path int true "Item ID"
+// @Param body body checklists.UpdateItemPositionRequestBody true "request body"
+// @Success 204
+// @Security ApiKeyAuth
+// @Router /checklists/{checklist_id}/items/{item_id}/position [put]
+func updateChecklistItemPosition(w http.ResponseWriter, httpReq *http.Request) {
+	var req = checklists.UpdateItemPositionRequest{
+		ChecklistId: getChecklistId(httpReq),
+		ItemId:      getItemId(httpReq),
+	}
+	handleRequest(w, httpReq, &req)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// readHeaderTimeout protects from clients which hold connections by sending headers slowly
+var readHeaderTimeout = 10 * time.Second
+
+// readTimeout must be long enough to upload attachment
+var readTimeout = 5 * time.Minute
+
+// writeTimeout must be longer than longTimeout, event streams are closed after it and clients reconnect
+var writeTimeout = 10 * time.Minute
+
+var idleTimeout = 2 * time.Minute
+
+// shutdownTimeout is period given to in-flight requests to complete on shutdown
+var shutdownTimeout = 30 * time.Second
+
+// shuttingDown is closed when server starts shutdown, so event streams, which never complete by themselves,
+// are closed instead of holding shutdown until its deadline
+var shuttingDown = make(chan struct{})
+
+// Init sets timeouts from env variables in Go duration format, e.g. 30s:
+// REQUEST_TIMEOUT and LONG_REQUEST_TIMEOUT limit request handling, HTTP_READ_HEADER_TIMEOUT,
+// HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT limit connections
+// and SHUTDOWN_TIMEOUT limits draining of in-flight requests.
+func Init() error {
+	for name, timeout := range map[string]*time.Duration{
+		"REQUEST_TIMEOUT":          &defaultTimeout,
+		"LONG_REQUEST_TIMEOUT":     &longTimeout,
+		"HTTP_READ_HEADER_TIMEOUT": &readHeaderTimeout,
+		"HTTP_READ_TIMEOUT":        &readTimeout,
+		"HTTP_WRITE_TIMEOUT":       &writeTimeout,
+		"HTTP_IDLE_TIMEOUT":        &idleTimeout,
+		"SHUTDOWN_TIMEOUT":         &shutdownTimeout,
+	} {
+		if value := os.Getenv(name); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid %v %q", name, value)
+			}
+			*timeout = d
+		}
+	}
+	return nil
+}
+
+// NewServer returns server listening on PORT env variable
+func NewServer() *http.Server {
+	server := &http.Server{
+		Addr:              ":" + os.Getenv("PORT"),
+		Handler:           NewRouter(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	server.RegisterOnShutdown(func() {
+		close(shuttingDown)
+	})
+	return server
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests until shutdown timeout,
+// connections which are still active after it are closed
+func Shutdown(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return err
+	}
+	return nil
+}
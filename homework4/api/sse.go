@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/events"
+	"go.uber.org/zap"
+)
+
+// heartbeatInterval keeps idle streams alive behind proxies which close silent connections
+const heartbeatInterval = 30 * time.Second
+
+func getLastEventId(r *http.Request) common.Id {
+	id, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	return common.Id(id)
+}
+
+// streamEvents writes subscription events to response as server-sent events until client disconnects
+func streamEvents(w http.ResponseWriter, httpReq *http.Request, req *events.SubscribeRequest) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Ctx(httpReq.Context()).Error("response writer doesn't support flushing")
+		httpServerError(w)
+		return
+	}
+	req.SetCaller(getUserId(httpReq))
+	ctx, cancel := handlerContext(httpReq)
+	resp, err := resources.Handle(ctx, req)
+	// subscription outlives handling of request, it's closed when client disconnects
+	cancel()
+	if err != nil {
+		sendError(w, httpReq, err)
+		return
+	}
+	subscription := resp.(*events.Subscription)
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// notifications are delivered in commit order, so events with seq not greater than the last one are duplicates
+	lastSeq := req.LastEventId
+	if subscription.Reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, a := range subscription.Missed {
+		if !writeEvent(w, a) {
+			return
+		}
+		lastSeq = a.Seq
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-httpReq.Context().Done():
+			return
+		case <-shuttingDown:
+			// client reconnects to another instance with Last-Event-ID
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case a, ok := <-subscription.Events:
+			if !ok {
+				// subscriber fell behind, client reconnects with Last-Event-ID and gets missed events
+				return
+			}
+			if a.Seq <= lastSeq {
+				continue
+			}
+			if !writeEvent(w, a) {
+				return
+			}
+			lastSeq = a.Seq
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent names event as <resource_type>.<action>, e.g. task.move, and uses seq of activity as event id
+func writeEvent(w http.ResponseWriter, a common.Activity) bool {
+	data, err := json.Marshal(a)
+	if err != nil {
+		logger.Zap.Error("error while marshaling event", zap.Error(err))
+		return false
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s.%s\ndata: %s\n\n", a.Seq, a.ResourceType, a.Action, data)
+	return err == nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"go.uber.org/zap"
+)
+
+// defaultTimeout limits handling of requests which don't have timeout set by withTimeout
+var defaultTimeout = 10 * time.Second
+
+// longTimeout limits handling of requests which transfer whole projects or files, e.g. export and import
+var longTimeout = 5 * time.Minute
+
+const timeoutKey contextKey = "timeout"
+
+// withTimeout overrides default timeout of route, it's applied when request is handled,
+// so long routes can be nested in routers with default timeout
+func withTimeout(timeout *time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+			ctx := context.WithValue(httpReq.Context(), timeoutKey, *timeout)
+			next.ServeHTTP(w, httpReq.WithContext(ctx))
+		})
+	}
+}
+
+// handlerContext is context of request handling, which is done when client disconnects or route timeout expires
+func handlerContext(httpReq *http.Request) (context.Context, context.CancelFunc) {
+	timeout, ok := httpReq.Context().Value(timeoutKey).(time.Duration)
+	if !ok {
+		timeout = defaultTimeout
+	}
+	return context.WithTimeout(httpReq.Context(), timeout)
+}
+
+// sendContextError responds to request which handling is aborted because its context is done
+func sendContextError(w http.ResponseWriter, httpReq *http.Request, ctxErr error, err error) {
+	if ctxErr == context.DeadlineExceeded {
+		logger.Ctx(httpReq.Context()).Warn("request timed out", zap.Error(err))
+		http.Error(w, "request timed out", http.StatusServiceUnavailable)
+		return
+	}
+	// client has disconnected, so response isn't sent
+	logger.Ctx(httpReq.Context()).Info("request canceled", zap.Error(err))
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/tracing"
+)
+
+// traceRequest starts span of request, which is renamed to route pattern once request is routed
+func traceRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(httpReq.Context(), propagation.HeaderCarrier(httpReq.Header))
+		ctx, span := tracing.Tracer.Start(ctx, "HTTP "+httpReq.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", httpReq)...))
+		defer span.End()
+		ww := middleware.NewWrapResponseWriter(w, httpReq.ProtoMajor)
+		next.ServeHTTP(ww, httpReq.WithContext(ctx))
+		if route := chi.RouteContext(httpReq.Context()).RoutePattern(); route != "" {
+			span.SetName(httpReq.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRouteKey.String(route))
+		}
+		status := responseStatus(ww)
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		// client errors are expected responses of server, so they don't mark span as failed
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
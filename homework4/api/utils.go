@@ -1,15 +1,18 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/projects"
 	"github.com/go-playground/validator/v10"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
 
 	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
 	"github.com/go-chi/chi"
@@ -18,19 +21,20 @@ import (
 
 var validate = validator.New()
 
-func StartHttpServer() {
-	err := http.ListenAndServe(":"+get_port(), NewRouter())
-	logger.Zap.Fatal("http server termination", zap.Error(err))
-}
+const defaultLimit = 50
 
-func get_port() string {
-	return os.Getenv("PORT")
-}
+// responder writes response of handled request or error of its handling
+type responder func(w http.ResponseWriter, httpReq *http.Request, body interface{}, err error)
 
 func handleRequest(w http.ResponseWriter, httpReq *http.Request, req interface{}) {
+	handleRequestWith(w, httpReq, req, sendResponse)
+}
+
+// handleRequestWith is used by handlers which need response status other than chosen by sendResponse
+func handleRequestWith(w http.ResponseWriter, httpReq *http.Request, req interface{}, respond responder) {
 	body, err := ioutil.ReadAll(httpReq.Body)
 	if err != nil {
-		logger.Zap.Error("error while reading request body", zap.Error(err))
+		logger.Ctx(httpReq.Context()).Error("error while reading request body", zap.Error(err))
 		httpServerError(w)
 		return
 	}
@@ -41,12 +45,24 @@ func handleRequest(w http.ResponseWriter, httpReq *http.Request, req interface{}
 			return
 		}
 	}
+	if authReq, ok := req.(resources.Authenticated); ok {
+		authReq.SetCaller(getUserId(httpReq))
+	}
+	if condReq, ok := req.(resources.Conditional); ok {
+		condReq.SetPrecondition(getIfMatch(httpReq))
+	}
 	if err := validate.Struct(req); err != nil {
 		http.Error(w, formatValidationErrors(err), http.StatusUnprocessableEntity)
 		return
 	}
-	resp, err := req.(resources.Request).Handle()
-	sendResponse(w, httpReq, resp, err)
+	ctx, cancel := handlerContext(httpReq)
+	defer cancel()
+	resp, err := resources.Handle(ctx, req.(resources.Request))
+	if err != nil && ctx.Err() != nil {
+		sendContextError(w, httpReq, ctx.Err(), err)
+		return
+	}
+	respond(w, httpReq, resp, err)
 }
 
 func formatValidationErrors(err error) string {
@@ -56,34 +72,63 @@ func formatValidationErrors(err error) string {
 
 func sendResponse(w http.ResponseWriter, httpReq *http.Request, body interface{}, err error) {
 	if err != nil {
-		sendError(w, err)
+		sendError(w, httpReq, err)
 		return
 	}
 	switch httpReq.Method {
 	case "GET":
-		sendJSONResponse(w, http.StatusOK, body)
+		sendJSONResponse(w, httpReq, http.StatusOK, body)
 	case "POST":
-		w.Header().Set("Location", getLocation(httpReq, body.(resources.Resource)))
-		sendJSONResponse(w, http.StatusCreated, body)
-	case "PUT", "DELETE":
+		if body == nil {
+			// action which doesn't create resource, e.g. restore
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if resource, ok := body.(resources.Resource); ok {
+			w.Header().Set("Location", getLocation(httpReq, resource))
+		}
+		sendJSONResponse(w, httpReq, http.StatusCreated, body)
+	case "PUT", "PATCH", "DELETE":
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
 
-func sendError(w http.ResponseWriter, err error) {
+// sendOKResponse responds with 200 to POST requests, which perform actions instead of creating resources
+func sendOKResponse(w http.ResponseWriter, httpReq *http.Request, body interface{}, err error) {
+	if err != nil {
+		sendError(w, httpReq, err)
+		return
+	}
+	sendJSONResponse(w, httpReq, http.StatusOK, body)
+}
+
+func sendError(w http.ResponseWriter, httpReq *http.Request, err error) {
 	var genError = common.Error{}
 	if yes := errors.As(err, &genError); yes {
 		switch genError.Type {
 		case common.NotFound:
-			http.Error(w, "not found", http.StatusNotFound)
+			http.Error(w, genError.Description, http.StatusNotFound)
 		case common.Conflict:
 			http.Error(w, genError.Description, http.StatusConflict)
+		case common.BadRequest:
+			http.Error(w, genError.Description, http.StatusBadRequest)
+		case common.Unauthorized:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		case common.Forbidden:
+			http.Error(w, genError.Description, http.StatusForbidden)
+		case common.PreconditionFailed:
+			http.Error(w, genError.Description, http.StatusPreconditionFailed)
+		case common.Unprocessable:
+			http.Error(w, genError.Description, http.StatusUnprocessableEntity)
+		case common.PayloadTooLarge:
+			http.Error(w, genError.Description, http.StatusRequestEntityTooLarge)
 		case common.InternalError:
-			logger.Zap.Error("internal error", zap.Error(err))
+			logger.Ctx(httpReq.Context()).Error("internal error", zap.Error(err))
 			httpServerError(w)
 		}
 	} else {
-		logger.Zap.Error("unhandled internal error", zap.Error(err))
+		logger.Ctx(httpReq.Context()).Error("unhandled internal error", zap.Error(err))
 		httpServerError(w)
 	}
 }
@@ -95,26 +140,77 @@ func getLocation(httpReq *http.Request, resource resources.Resource) string {
 	// task resource has different base path after creation
 	case common.Task:
 		location = BasePath + "/tasks/" + id
+	case common.Checklist:
+		location = BasePath + "/checklists/" + id
+	case common.ProjectImport, projects.TrelloImport:
+		location = BasePath + "/projects/" + id
 	default:
 		location = httpReq.URL.Path + "/" + id
 	}
 	return location
 }
 
-func sendJSONResponse(w http.ResponseWriter, statusCode int, body interface{}) {
+// sendJSONResponse responds with 304 to GET request if body matches If-None-Match header
+func sendJSONResponse(w http.ResponseWriter, httpReq *http.Request, statusCode int, body interface{}) {
 	binBody, err := json.Marshal(body)
 	if err != nil {
-		logger.Zap.Error("error while marshaling response body", zap.Error(err))
+		logger.Ctx(httpReq.Context()).Error("error while marshaling response body", zap.Error(err))
 		httpServerError(w)
 		return
 	}
+	etag := getETag(body, binBody)
+	w.Header().Set("ETag", etag)
+	if httpReq.Method == "GET" && etagMatches(httpReq.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json;charset=utf-8")
 	w.WriteHeader(statusCode)
 	if _, err := w.Write(binBody); err != nil {
-		logger.Zap.Error("error while writing http response", zap.Error(err))
+		logger.Ctx(httpReq.Context()).Error("error while writing http response", zap.Error(err))
 	}
 }
 
+// getETag returns strong ETag with version for versioned resources
+// and weak ETag with hash of representation for others
+func getETag(body interface{}, binBody []byte) string {
+	if resource, ok := body.(resources.Versioned); ok && resource.GetVersion() != 0 {
+		return `"` + strconv.Itoa(int(resource.GetVersion())) + `"`
+	}
+	hash := sha256.Sum256(binBody)
+	return `W/"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// etagMatches uses weak comparison, as required for If-None-Match
+func etagMatches(header string, etag string) bool {
+	if header == "" {
+		return false
+	} else if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// getIfMatch returns 0 if header is absent or equals to "*",
+// it returns -1 for unknown ETags, since they never match version
+func getIfMatch(r *http.Request) common.Version {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0
+	}
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 || header != `"`+strconv.Itoa(version)+`"` {
+		return -1
+	}
+	return common.Version(version)
+}
+
 func httpServerError(w http.ResponseWriter) {
 	http.Error(w, "server error", http.StatusInternalServerError)
 }
@@ -132,8 +228,46 @@ func getTaskId(r *http.Request) common.Id { return getId(r, "taskID") }
 
 func getCommentId(r *http.Request) common.Id { return getId(r, "commentID") }
 
+func getLabelId(r *http.Request) common.Id { return getId(r, "labelID") }
+
+func getChecklistId(r *http.Request) common.Id { return getId(r, "checklistID") }
+
+func getAttachmentId(r *http.Request) common.Id { return getId(r, "attachmentID") }
+
+func getWebhookId(r *http.Request) common.Id { return getId(r, "webhookID") }
+
+func getItemId(r *http.Request) common.Id { return getId(r, "itemID") }
+
+func getTargetUserId(r *http.Request) common.Id { return getId(r, "userID") }
+
 func getExpanded(r *http.Request) bool {
 	query := r.URL.Query()
 	expanded, prs := query["expanded"]
 	return prs && (expanded[0] == "" || expanded[0] == "true")
 }
+
+func getIdQueryParam(r *http.Request, key string) common.Id {
+	id, _ := strconv.Atoi(getQueryParam(r, key))
+	return common.Id(id)
+}
+
+func getQueryParam(r *http.Request, key string) string {
+	return r.URL.Query().Get(key)
+}
+
+func getQueryParamOrDefault(r *http.Request, key string, defaultValue string) string {
+	if value := getQueryParam(r, key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getLimit returns 0 if limit is not a number, so it will not pass validation
+func getLimit(r *http.Request) int {
+	value := getQueryParam(r, "limit")
+	if value == "" {
+		return defaultLimit
+	}
+	limit, _ := strconv.Atoi(value)
+	return limit
+}
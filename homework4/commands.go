@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/projects"
+)
+
+// runCommand runs subcommand instead of http server, it's called after db initialization
+func runCommand(args []string) error {
+	switch args[0] {
+	case "import-trello":
+		return importTrello(args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// importTrello imports Trello board JSON export and prints mapping of Trello ids to created ones
+func importTrello(args []string) error {
+	flags := flag.NewFlagSet("import-trello", flag.ExitOnError)
+	ownerName := flags.String("owner", "", "name of user who becomes owner of the project")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import-trello -owner <user name> <board.json>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *ownerName == "" || flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("owner and board file are required")
+	}
+	ctx := context.Background()
+	owner, _, err := db.Query().Users().GetByName(ctx, *ownerName)
+	if err != nil {
+		return fmt.Errorf("cannot get owner: %w", err)
+	}
+	data, err := ioutil.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	req := projects.TrelloImportRequest{}
+	if err := json.Unmarshal(data, &req.TrelloBoard); err != nil {
+		return fmt.Errorf("invalid board file: %w", err)
+	}
+	req.SetCaller(owner.Id)
+	result, err := req.Handle(ctx)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,113 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/jackc/pgx/v4"
+)
+
+type QueryerWrap common.QueryerWrap
+
+func (w QueryerWrap) Create(ctx context.Context, a rcommon.Activity) (id rcommon.Id, err error) {
+	const q = `
+		INSERT INTO activity (project_id, task_id, actor_id, create_dt, resource_type, resource_id, action, diff)
+		VALUES ($1, $2, $3, NOW(), $4, $5, $6, $7)
+		RETURNING id
+	`
+	err = w.Q.QueryRow(ctx, q, a.ProjectId, a.TaskId, a.ActorId,
+		a.ResourceType, a.ResourceId, a.Action, a.Diff).Scan(&id)
+	return id, err
+}
+
+func (w QueryerWrap) Get(ctx context.Context, id rcommon.Id) (rcommon.Activity, error) {
+	const q = `
+		SELECT id, COALESCE(seq, 0), project_id, task_id, COALESCE(actor_id, 0), create_dt, resource_type, resource_id, action, diff
+		FROM activity
+		WHERE id = $1
+	`
+	rows, err := w.Q.Query(ctx, q, id)
+	if err != nil {
+		return rcommon.Activity{}, err
+	}
+	defer rows.Close()
+	activity, err := scanActivity(rows)
+	if err != nil {
+		return rcommon.Activity{}, err
+	} else if len(activity) == 0 {
+		return rcommon.Activity{}, common.ErrNoRows
+	}
+	return activity[0], nil
+}
+
+// GetByProjectAfterSeq returns at most limit activity entries of project committed after entry with afterSeq
+// in commit order
+func (w QueryerWrap) GetByProjectAfterSeq(ctx context.Context, projectId rcommon.Id, afterSeq rcommon.Id, limit int) ([]rcommon.Activity, error) {
+	const q = `
+		SELECT id, COALESCE(seq, 0), project_id, task_id, COALESCE(actor_id, 0), create_dt, resource_type, resource_id, action, diff
+		FROM activity
+		WHERE project_id = $1 AND seq > $2
+		ORDER BY seq
+		LIMIT $3
+	`
+	rows, err := w.Q.Query(ctx, q, projectId, afterSeq, limit)
+	if err != nil {
+		return []rcommon.Activity{}, err
+	}
+	defer rows.Close()
+	return scanActivity(rows)
+}
+
+// GetByProject returns at most limit activity entries of project newest first,
+// only entries with id less than beforeId are returned if it's not 0
+func (w QueryerWrap) GetByProject(ctx context.Context, projectId rcommon.Id, beforeId rcommon.Id, limit int) ([]rcommon.Activity, error) {
+	const q = `
+		SELECT id, COALESCE(seq, 0), project_id, task_id, COALESCE(actor_id, 0), create_dt, resource_type, resource_id, action, diff
+		FROM activity
+		WHERE project_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+	rows, err := w.Q.Query(ctx, q, projectId, beforeId, limit)
+	if err != nil {
+		return []rcommon.Activity{}, err
+	}
+	defer rows.Close()
+	return scanActivity(rows)
+}
+
+// GetByTask returns activity of task and its sub-resources, see GetByProject
+func (w QueryerWrap) GetByTask(ctx context.Context, taskId rcommon.Id, beforeId rcommon.Id, limit int) ([]rcommon.Activity, error) {
+	const q = `
+		SELECT id, COALESCE(seq, 0), project_id, task_id, COALESCE(actor_id, 0), create_dt, resource_type, resource_id, action, diff
+		FROM activity
+		WHERE task_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+	rows, err := w.Q.Query(ctx, q, taskId, beforeId, limit)
+	if err != nil {
+		return []rcommon.Activity{}, err
+	}
+	defer rows.Close()
+	return scanActivity(rows)
+}
+
+func scanActivity(rows pgx.Rows) ([]rcommon.Activity, error) {
+	activity := []rcommon.Activity{}
+	for rows.Next() {
+		a := rcommon.Activity{}
+		var diff []byte
+		err := rows.Scan(&a.Id, &a.Seq, &a.ProjectId, &a.TaskId, &a.ActorId, &a.CreatedAt,
+			&a.ResourceType, &a.ResourceId, &a.Action, &diff)
+		if err != nil {
+			return activity, err
+		}
+		if err := json.Unmarshal(diff, &a.Diff); err != nil {
+			return activity, err
+		}
+		activity = append(activity, a)
+	}
+	return activity, rows.Err()
+}
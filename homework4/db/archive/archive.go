@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"context"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+func (w QueryerWrap) GetProject(ctx context.Context, projectId rcommon.Id) (rcommon.ArchivedProject, error) {
+	p := rcommon.ArchivedProject{Id: projectId}
+	const q = `
+		SELECT name, description, wip_limits_advisory, create_dt FROM projects
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := w.Q.QueryRow(ctx, q, projectId).
+		Scan(&p.Name, &p.Description, &p.WipLimitsAdvisory, &p.CreatedAt)
+	return p, err
+}
+
+// GetColumns returns columns without tasks
+func (w QueryerWrap) GetColumns(ctx context.Context, projectId rcommon.Id) ([]rcommon.ArchivedColumn, error) {
+	columns := []rcommon.ArchivedColumn{}
+	const q = `
+		SELECT id, name, wip_limit FROM columns
+		WHERE project_id = $1 AND deleted_at IS NULL
+		ORDER BY rank ASC
+	`
+	rows, err := w.Q.Query(ctx, q, projectId)
+	if err != nil {
+		return columns, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		c := rcommon.ArchivedColumn{Tasks: []rcommon.ArchivedTask{}}
+		if err := rows.Scan(&c.Id, &c.Name, &c.WipLimit); err != nil {
+			return columns, err
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// GetTasks returns column tasks with comments in column order
+func (w QueryerWrap) GetTasks(ctx context.Context, columnId rcommon.Id) ([]rcommon.ArchivedTask, error) {
+	tasks := []rcommon.ArchivedTask{}
+	const q = `
+		SELECT t.id, t.name, t.description, t.assignee_id, to_char(t.due_date, 'YYYY-MM-DD'), t.priority, t.estimate,
+			   COALESCE((
+				   SELECT json_agg(tl.label_id ORDER BY tl.label_id)
+				   FROM task_labels tl
+				   WHERE tl.task_id = t.id
+			   ), '[]'),
+			   COALESCE((
+				   SELECT json_agg(json_build_object('id', c.id, 'text', c.text, 'created_at', c.create_dt)
+								   ORDER BY c.create_dt, c.id)
+				   FROM comments c
+				   WHERE c.task_id = t.id AND c.deleted_at IS NULL
+			   ), '[]')
+		FROM tasks t
+		WHERE t.column_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.rank ASC
+	`
+	rows, err := w.Q.Query(ctx, q, columnId)
+	if err != nil {
+		return tasks, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		t := rcommon.ArchivedTask{}
+		err := rows.Scan(&t.Id, &t.Name, &t.Description, &t.AssigneeId, &t.DueDate, &t.Priority, &t.Estimate,
+			&t.LabelIds, &t.Comments)
+		if err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// CreateComment creates comment with given creation time, unlike comments.Create
+func (w QueryerWrap) CreateComment(ctx context.Context, taskId rcommon.Id, text string, createdAt time.Time) (rcommon.Id, error) {
+	var id rcommon.Id
+	const q = "INSERT INTO comments (task_id, text, create_dt, update_dt) VALUES ($1, $2, $3, $3) RETURNING id"
+	err := w.Q.QueryRow(ctx, q, taskId, text, createdAt).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,88 @@
+package attachments
+
+import (
+	"context"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+// Create saves metadata of attachment, which content is already stored by attachment.StorageKey
+func (w QueryerWrap) Create(ctx context.Context, a rcommon.Attachment) (rcommon.Attachment, error) {
+	const q = `
+		INSERT INTO attachments (task_id, name, content_type, size, storage_key) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, create_dt
+	`
+	err := w.Q.QueryRow(ctx, q, a.TaskId, a.Name, a.ContentType, a.Size, a.StorageKey).
+		Scan(&a.Id, &a.CreatedAt)
+	return a, err
+}
+
+func (w QueryerWrap) Get(ctx context.Context, taskId, attachmentId rcommon.Id) (rcommon.Attachment, error) {
+	a := rcommon.Attachment{Id: attachmentId, TaskId: taskId}
+	const q = `
+		SELECT name, content_type, size, storage_key, create_dt FROM attachments
+		WHERE task_id = $1 AND id = $2
+	`
+	err := w.Q.QueryRow(ctx, q, taskId, attachmentId).
+		Scan(&a.Name, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	return a, err
+}
+
+func (w QueryerWrap) GetMultiple(ctx context.Context, taskId rcommon.Id) ([]rcommon.Attachment, error) {
+	attachments := []rcommon.Attachment{}
+	const q = `
+		SELECT id, name, content_type, size, storage_key, create_dt FROM attachments
+		WHERE task_id = $1
+		ORDER BY create_dt, id
+	`
+	rows, err := w.Q.Query(ctx, q, taskId)
+	if err != nil {
+		return attachments, err
+	}
+	defer rows.Close()
+	a := rcommon.Attachment{TaskId: taskId}
+	for rows.Next() {
+		if err := rows.Scan(&a.Id, &a.Name, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+			return attachments, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (w QueryerWrap) Delete(ctx context.Context, taskId, attachmentId rcommon.Id) error {
+	const q = "DELETE FROM attachments WHERE task_id = $1 AND id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, attachmentId))
+}
+
+// DeleteOfPurgedTasks deletes attachments of tasks which are going to be purged from trash,
+// it returns storage keys of deleted attachments, so their content can be deleted after commit
+func (w QueryerWrap) DeleteOfPurgedTasks(ctx context.Context, retention time.Duration) ([]string, error) {
+	const q = `
+		DELETE FROM attachments
+		WHERE task_id IN (SELECT id FROM tasks WHERE deleted_at < NOW() - $1 * INTERVAL '1 second')
+		RETURNING storage_key
+	`
+	return w.deleteReturningKeys(ctx, q, retention.Seconds())
+}
+
+func (w QueryerWrap) deleteReturningKeys(ctx context.Context, q string, args ...interface{}) ([]string, error) {
+	keys := []string{}
+	rows, err := w.Q.Query(ctx, q, args...)
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+	var key string
+	for rows.Next() {
+		if err := rows.Scan(&key); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,257 @@
+package checklists
+
+import (
+	"context"
+	"fmt"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+func (w QueryerWrap) Create(ctx context.Context, taskId rcommon.Id, name string) (rcommon.Checklist, error) {
+	c := rcommon.Checklist{
+		TaskId:                  taskId,
+		ChecklistSettableFields: rcommon.ChecklistSettableFields{Name: name},
+		Items:                   []rcommon.ChecklistItem{},
+	}
+	const q = "INSERT INTO checklists (task_id, name) VALUES ($1, $2) RETURNING id, version"
+	err := w.Q.QueryRow(ctx, q, taskId, name).Scan(&c.Id, &c.Version)
+	return c, err
+}
+
+// GetTaskId is used for access check before checklist is read
+func (w QueryerWrap) GetTaskId(ctx context.Context, checklistId rcommon.Id) (taskId rcommon.Id, err error) {
+	const q = "SELECT task_id FROM checklists WHERE id = $1"
+	err = w.Q.QueryRow(ctx, q, checklistId).Scan(&taskId)
+	return taskId, err
+}
+
+// Get returns checklist without items
+func (w QueryerWrap) Get(ctx context.Context, checklistId rcommon.Id) (rcommon.Checklist, error) {
+	c := rcommon.Checklist{Id: checklistId}
+	const q = "SELECT task_id, name, version FROM checklists WHERE id = $1"
+	err := w.Q.QueryRow(ctx, q, checklistId).Scan(&c.TaskId, &c.Name, &c.Version)
+	return c, err
+}
+
+func (w QueryerWrap) GetExpanded(ctx context.Context, checklistId rcommon.Id) (rcommon.Checklist, error) {
+	c, err := w.Get(ctx, checklistId)
+	if err != nil {
+		return c, err
+	}
+	c.Items, err = w.GetItems(ctx, checklistId)
+	return c, err
+}
+
+// GetMultiple returns task checklists with items in order of creation
+func (w QueryerWrap) GetMultiple(ctx context.Context, taskId rcommon.Id) ([]rcommon.Checklist, error) {
+	checklists := []rcommon.Checklist{}
+	const q = `
+		SELECT cl.id, cl.name, cl.version, COALESCE(i.id, 0), COALESCE(i.text, ''), COALESCE(i.done, false)
+		FROM checklists cl
+		LEFT JOIN checklist_items i ON i.checklist_id = cl.id
+		WHERE cl.task_id = $1
+		ORDER BY cl.id, i.rank
+	`
+	rows, err := w.Q.Query(ctx, q, taskId)
+	if err != nil {
+		return checklists, err
+	}
+	defer rows.Close()
+	c := rcommon.Checklist{TaskId: taskId}
+	item := rcommon.ChecklistItem{}
+	i := -1
+	for rows.Next() {
+		err := rows.Scan(&c.Id, &c.Name, &c.Version, &item.Id, &item.Text, &item.Done)
+		if err != nil {
+			return checklists, err
+		}
+		if i == -1 || checklists[i].Id != c.Id {
+			c.Items = []rcommon.ChecklistItem{}
+			checklists = append(checklists, c)
+			i++
+		}
+		if item.Id != 0 {
+			checklists[i].Items = append(checklists[i].Items, item)
+		}
+	}
+	return checklists, rows.Err()
+}
+
+// Update modifies checklist only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) Update(ctx context.Context, checklistId rcommon.Id, name string, version rcommon.Version) error {
+	const q = `
+		UPDATE checklists SET name = $2, version = version + 1
+		WHERE id = $1 AND ($3 = 0 OR version = $3)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, checklistId, name, version))
+}
+
+var patchableColumns = map[string]string{"name": ""}
+
+// Patch modifies only columns of given values, see Update
+func (w QueryerWrap) Patch(ctx context.Context, checklistId rcommon.Id, values map[string]interface{}, version rcommon.Version) error {
+	assignments, args, err := common.BuildAssignments(patchableColumns, values, 3)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		UPDATE checklists SET %v, version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)
+	`, assignments)
+	args = append([]interface{}{checklistId, version}, args...)
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, args...))
+}
+
+// Delete deletes checklist with its items permanently, see Update
+func (w QueryerWrap) Delete(ctx context.Context, checklistId rcommon.Id, version rcommon.Version) error {
+	const q = "DELETE FROM checklists WHERE id = $1 AND ($2 = 0 OR version = $2)"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, checklistId, version))
+}
+
+// IncrementVersion is called on change of checklist items, it blocks checklist, so items changes are serialized
+func (w QueryerWrap) IncrementVersion(ctx context.Context, checklistId rcommon.Id) error {
+	const q = "UPDATE checklists SET version = version + 1 WHERE id = $1"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, checklistId))
+}
+
+func (w QueryerWrap) CreateItem(ctx context.Context, checklistId rcommon.Id,
+	fields rcommon.ChecklistItemSettableFields, rank rcommon.Rank) (rcommon.ChecklistItem, error) {
+	item := rcommon.ChecklistItem{ChecklistItemSettableFields: fields}
+	const q = `
+		INSERT INTO checklist_items (checklist_id, text, done, rank) VALUES ($1, $2, $3, $4)
+		RETURNING id, version
+	`
+	err := w.Q.QueryRow(ctx, q, checklistId, fields.Text, fields.Done, rank).
+		Scan(&item.Id, &item.Version)
+	return item, err
+}
+
+func (w QueryerWrap) GetItem(ctx context.Context, checklistId, itemId rcommon.Id) (rcommon.ChecklistItem, error) {
+	item := rcommon.ChecklistItem{Id: itemId}
+	const q = "SELECT text, done, version FROM checklist_items WHERE checklist_id = $1 AND id = $2"
+	err := w.Q.QueryRow(ctx, q, checklistId, itemId).Scan(&item.Text, &item.Done, &item.Version)
+	return item, err
+}
+
+func (w QueryerWrap) GetItems(ctx context.Context, checklistId rcommon.Id) ([]rcommon.ChecklistItem, error) {
+	items := []rcommon.ChecklistItem{}
+	const q = "SELECT id, text, done FROM checklist_items WHERE checklist_id = $1 ORDER BY rank ASC"
+	rows, err := w.Q.Query(ctx, q, checklistId)
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+	item := rcommon.ChecklistItem{}
+	for rows.Next() {
+		if err := rows.Scan(&item.Id, &item.Text, &item.Done); err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// UpdateItem modifies item only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) UpdateItem(ctx context.Context, checklistId, itemId rcommon.Id,
+	fields rcommon.ChecklistItemSettableFields, version rcommon.Version) error {
+	const q = `
+		UPDATE checklist_items SET text = $3, done = $4, version = version + 1
+		WHERE checklist_id = $1 AND id = $2 AND ($5 = 0 OR version = $5)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, checklistId, itemId,
+		fields.Text, fields.Done, version))
+}
+
+var patchableItemColumns = map[string]string{"text": "", "done": ""}
+
+// PatchItem modifies only columns of given values, see UpdateItem
+func (w QueryerWrap) PatchItem(ctx context.Context, checklistId, itemId rcommon.Id, values map[string]interface{}, version rcommon.Version) error {
+	assignments, args, err := common.BuildAssignments(patchableItemColumns, values, 4)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		UPDATE checklist_items SET %v, version = version + 1
+		WHERE checklist_id = $1 AND id = $2 AND ($3 = 0 OR version = $3)
+	`, assignments)
+	args = append([]interface{}{checklistId, itemId, version}, args...)
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, args...))
+}
+
+// DeleteItem deletes item permanently, see UpdateItem
+func (w QueryerWrap) DeleteItem(ctx context.Context, checklistId, itemId rcommon.Id, version rcommon.Version) error {
+	const q = "DELETE FROM checklist_items WHERE checklist_id = $1 AND id = $2 AND ($3 = 0 OR version = $3)"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, checklistId, itemId, version))
+}
+
+func (w QueryerWrap) GetMaxItemRank(ctx context.Context, checklistId rcommon.Id) (rank rcommon.Rank, err error) {
+	const q = `
+		SELECT rank FROM checklist_items
+		WHERE checklist_id = $1
+		ORDER BY rank DESC
+		LIMIT 1
+	`
+	err = w.Q.QueryRow(ctx, q, checklistId).Scan(&rank)
+	return rank, err
+}
+
+func (w QueryerWrap) GetItemRank(ctx context.Context, checklistId, itemId rcommon.Id) (rank rcommon.Rank, err error) {
+	const q = "SELECT rank FROM checklist_items WHERE checklist_id = $1 AND id = $2"
+	err = w.Q.QueryRow(ctx, q, checklistId, itemId).Scan(&rank)
+	return rank, err
+}
+
+func (w QueryerWrap) GetNextItemRank(ctx context.Context, checklistId rcommon.Id, rank rcommon.Rank) (nextRank rcommon.Rank, err error) {
+	const q = `
+		SELECT rank FROM checklist_items
+		WHERE checklist_id = $1 AND rank > $2
+		ORDER BY rank
+		LIMIT 1
+	`
+	err = w.Q.QueryRow(ctx, q, checklistId, rank).Scan(&nextRank)
+	return nextRank, err
+}
+
+func (w QueryerWrap) UpdateItemRank(ctx context.Context, checklistId, itemId rcommon.Id, rank rcommon.Rank) error {
+	const q = "UPDATE checklist_items SET rank = $3 WHERE checklist_id = $1 AND id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, checklistId, itemId, rank))
+}
+
+func (w QueryerWrap) GetItemsIds(ctx context.Context, checklistId rcommon.Id) ([]rcommon.Id, error) {
+	var ids = []rcommon.Id{}
+	const q = `SELECT id FROM checklist_items WHERE checklist_id = $1 ORDER BY rank ASC`
+	rows, err := w.Q.Query(ctx, q, checklistId)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+	var id rcommon.Id
+	for rows.Next() {
+		if err := rows.Scan(&id); err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateItemsRanks sets ranks of items without changing their versions, see tasks.UpdateRanks
+func (w QueryerWrap) UpdateItemsRanks(ctx context.Context, itemsIds []rcommon.Id, ranks []rcommon.Rank) error {
+	const q = `
+		UPDATE checklist_items i SET rank = r.rank
+		FROM unnest($1::integer[], $2::text[]) AS r(id, rank)
+		WHERE i.id = r.id
+	`
+	ids := make([]int, len(itemsIds))
+	for i, id := range itemsIds {
+		ids[i] = int(id)
+	}
+	strRanks := make([]string, len(ranks))
+	for i, rank := range ranks {
+		strRanks[i] = string(rank)
+	}
+	_, err := w.Q.Exec(ctx, q, ids, strRanks)
+	return err
+}
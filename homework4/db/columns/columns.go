@@ -2,13 +2,54 @@ package columns
 
 import (
 	"context"
+	"fmt"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
 	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
 )
 
 type QueryerWrap common.QueryerWrap
 
-func (w QueryerWrap) GetAndBlockMaxRank(projectId rcommon.Id) (rank rcommon.Rank, err error) {
+// GetAndBlockIds returns ids of all project columns including trashed ones ordered by rank
+func (w QueryerWrap) GetAndBlockIds(ctx context.Context, projectId rcommon.Id) ([]rcommon.Id, error) {
+	var ids = []rcommon.Id{}
+	const q = `SELECT id FROM columns WHERE project_id = $1 ORDER BY rank ASC FOR UPDATE`
+	rows, err := w.Q.Query(ctx, q, projectId)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+	var id rcommon.Id
+	for rows.Next() {
+		err := rows.Scan(&id)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// UpdateRanks sets ranks of columns without changing their versions, see tasks.UpdateRanks
+func (w QueryerWrap) UpdateRanks(ctx context.Context, columnsIds []rcommon.Id, ranks []rcommon.Rank) error {
+	const q = `
+		UPDATE columns c SET rank = r.rank
+		FROM unnest($1::integer[], $2::text[]) AS r(id, rank)
+		WHERE c.id = r.id
+	`
+	ids := make([]int, len(columnsIds))
+	for i, id := range columnsIds {
+		ids[i] = int(id)
+	}
+	strRanks := make([]string, len(ranks))
+	for i, rank := range ranks {
+		strRanks[i] = string(rank)
+	}
+	_, err := w.Q.Exec(ctx, q, ids, strRanks)
+	return err
+}
+
+// GetAndBlockMaxRank takes trashed columns into account, so their ranks stay unique after restore
+func (w QueryerWrap) GetAndBlockMaxRank(ctx context.Context, projectId rcommon.Id) (rank rcommon.Rank, err error) {
 	const q = `
 		SELECT rank FROM columns
 		WHERE project_id = $1
@@ -16,38 +57,46 @@ func (w QueryerWrap) GetAndBlockMaxRank(projectId rcommon.Id) (rank rcommon.Rank
 		LIMIT 1
 		FOR UPDATE
 	`
-	err = w.Q.QueryRow(context.Background(), q, projectId).Scan(&rank)
+	err = w.Q.QueryRow(ctx, q, projectId).Scan(&rank)
 	return rank, err
 }
 
-func (w QueryerWrap) Create(projectId rcommon.Id, name string, rank rcommon.Rank) (rcommon.ColumnExpanded, error) {
+func (w QueryerWrap) Create(ctx context.Context, projectId rcommon.Id, fields rcommon.ColumnSettableFields, rank rcommon.Rank) (rcommon.ColumnExpanded, error) {
 	c := rcommon.ColumnExpanded{
-		Column: rcommon.Column{ColumnSettableFields: rcommon.ColumnSettableFields{Name: name}},
+		Column: rcommon.Column{ColumnSettableFields: fields},
 		Tasks:  []rcommon.Task{},
 	}
-	const q = `INSERT INTO columns (project_id, name, rank) VALUES ($1, $2, $3) RETURNING id`
-	err := w.Q.QueryRow(context.Background(), q, projectId, name, rank).Scan(&c.Id)
+	const q = `INSERT INTO columns (project_id, name, wip_limit, rank) VALUES ($1, $2, $3, $4) RETURNING id, version`
+	err := w.Q.QueryRow(ctx, q, projectId, fields.Name, fields.WipLimit, rank).Scan(&c.Id, &c.Version)
 	return c, err
 }
 
-func (w QueryerWrap) Get(projectId, columnId rcommon.Id) (rcommon.Column, error) {
+func (w QueryerWrap) Get(ctx context.Context, projectId, columnId rcommon.Id) (rcommon.Column, error) {
 	c := rcommon.Column{Id: columnId}
-	const q = `SELECT name FROM columns WHERE project_id = $1 AND id = $2`
-	err := w.Q.QueryRow(context.Background(), q, projectId, columnId).Scan(&c.Name)
+	const q = `
+		SELECT name, wip_limit, version FROM columns
+		WHERE project_id = $1 AND id = $2 AND deleted_at IS NULL
+	`
+	err := w.Q.QueryRow(ctx, q, projectId, columnId).Scan(&c.Name, &c.WipLimit, &c.Version)
 	return c, err
 }
 
-func (w QueryerWrap) GetMultiple(projectId rcommon.Id) ([]rcommon.Column, error) {
+func (w QueryerWrap) GetMultiple(ctx context.Context, projectId rcommon.Id) ([]rcommon.Column, error) {
 	columns := []rcommon.Column{}
-	const q = "SELECT id, name FROM columns WHERE project_id = $1 ORDER BY rank ASC"
-	rows, err := w.Q.Query(context.Background(), q, projectId)
+	const q = `
+		SELECT id, name, wip_limit FROM columns
+		WHERE project_id = $1 AND deleted_at IS NULL
+		ORDER BY rank ASC
+	`
+	rows, err := w.Q.Query(ctx, q, projectId)
 	if err != nil {
 		return columns, err
 	}
 	defer rows.Close()
-	c := rcommon.Column{}
 	for rows.Next() {
-		err := rows.Scan(&c.Id, &c.Name)
+		// wip limit is scanned into new pointer, so column must not be reused
+		c := rcommon.Column{}
+		err := rows.Scan(&c.Id, &c.Name, &c.WipLimit)
 		if err != nil {
 			return columns, err
 		}
@@ -56,33 +105,79 @@ func (w QueryerWrap) GetMultiple(projectId rcommon.Id) ([]rcommon.Column, error)
 	return columns, nil
 }
 
-func (w QueryerWrap) Update(projectId, columnId rcommon.Id, name string) error {
-	const q = `UPDATE columns SET name = $3 WHERE project_id = $1 AND id = $2`
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, projectId, columnId, name))
+// Update modifies column only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) Update(ctx context.Context, projectId, columnId rcommon.Id, fields rcommon.ColumnSettableFields, version rcommon.Version) error {
+	const q = `
+		UPDATE columns SET name = $3, wip_limit = $4, version = version + 1
+		WHERE project_id = $1 AND id = $2 AND deleted_at IS NULL AND ($5 = 0 OR version = $5)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, columnId,
+		fields.Name, fields.WipLimit, version))
+}
+
+var patchableColumns = map[string]string{"name": "", "wip_limit": ""}
+
+// Patch modifies only columns of given values, see Update
+func (w QueryerWrap) Patch(ctx context.Context, projectId, columnId rcommon.Id, values map[string]interface{}, version rcommon.Version) error {
+	assignments, args, err := common.BuildAssignments(patchableColumns, values, 4)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		UPDATE columns SET %v, version = version + 1
+		WHERE project_id = $1 AND id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+	`, assignments)
+	args = append([]interface{}{projectId, columnId, version}, args...)
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, args...))
 }
 
-func (w QueryerWrap) GetAndBlockRank(projectId, columnId rcommon.Id) (rank rcommon.Rank, err error) {
-	const q = `SELECT rank FROM columns WHERE project_id = $1 AND id = $2 FOR UPDATE`
-	err = w.Q.QueryRow(context.Background(), q, projectId, columnId).Scan(&rank)
+func (w QueryerWrap) GetAndBlockRank(ctx context.Context, projectId, columnId rcommon.Id) (rank rcommon.Rank, err error) {
+	const q = `SELECT rank FROM columns WHERE project_id = $1 AND id = $2 AND deleted_at IS NULL FOR UPDATE`
+	err = w.Q.QueryRow(ctx, q, projectId, columnId).Scan(&rank)
 	return rank, err
 }
 
-func (w QueryerWrap) Delete(columnId rcommon.Id) error {
-	const q = `DELETE FROM columns WHERE id = $1`
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, columnId))
+// Delete moves column to trash only if its version is equal to given one, 0 version matches any,
+// column tasks must be moved to another column before
+func (w QueryerWrap) Delete(ctx context.Context, columnId rcommon.Id, version rcommon.Version) error {
+	const q = `
+		UPDATE columns SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, columnId, version))
 }
 
-func (w QueryerWrap) GetAndBlockSuccessorColumnId(projectId rcommon.Id, rank rcommon.Rank) (id rcommon.Id, err error) {
+// GetDeleted returns column from trash
+func (w QueryerWrap) GetDeleted(ctx context.Context, projectId, columnId rcommon.Id) (rcommon.Column, error) {
+	c := rcommon.Column{Id: columnId}
+	const q = `
+		SELECT name, wip_limit, version FROM columns
+		WHERE project_id = $1 AND id = $2 AND deleted_at IS NOT NULL
+	`
+	err := w.Q.QueryRow(ctx, q, projectId, columnId).Scan(&c.Name, &c.WipLimit, &c.Version)
+	return c, err
+}
+
+// Restore restores column from trash to its former position
+func (w QueryerWrap) Restore(ctx context.Context, projectId, columnId rcommon.Id) error {
+	const q = `
+		UPDATE columns SET deleted_at = NULL, version = version + 1
+		WHERE project_id = $1 AND id = $2 AND deleted_at IS NOT NULL
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, columnId))
+}
+
+func (w QueryerWrap) GetAndBlockSuccessorColumnId(ctx context.Context, projectId rcommon.Id, rank rcommon.Rank) (id rcommon.Id, err error) {
 	const q = `
 		WITH before AS (
 			SELECT id FROM columns
-			WHERE project_id = $1 AND rank < $2
+			WHERE project_id = $1 AND rank < $2 AND deleted_at IS NULL
 			ORDER BY rank
 			LIMIT 1
 			FOR UPDATE
 		), after AS (
 			SELECT id FROM columns
-			WHERE NOT EXISTS(SELECT * FROM before) AND project_id = $1 AND rank > $2
+			WHERE NOT EXISTS(SELECT * FROM before) AND project_id = $1 AND rank > $2 AND deleted_at IS NULL
 			ORDER BY rank
 			LIMIT 1
 			FOR UPDATE
@@ -91,29 +186,44 @@ func (w QueryerWrap) GetAndBlockSuccessorColumnId(projectId rcommon.Id, rank rco
 		UNION
 		SELECT * FROM after
 	`
-	err = w.Q.QueryRow(context.Background(), q, projectId, rank).Scan(&id)
+	err = w.Q.QueryRow(ctx, q, projectId, rank).Scan(&id)
 	return id, err
 }
 
-func (w QueryerWrap) UpdateRank(projectId, columnId rcommon.Id, rank rcommon.Rank) error {
-	const q = `UPDATE columns SET rank = $3 WHERE project_id = $1 AND id = $2`
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, projectId, columnId, rank))
+func (w QueryerWrap) UpdateRank(ctx context.Context, projectId, columnId rcommon.Id, rank rcommon.Rank) error {
+	const q = `UPDATE columns SET rank = $3 WHERE project_id = $1 AND id = $2 AND deleted_at IS NULL`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, columnId, rank))
 }
 
-func (w QueryerWrap) GetNextRank(projectId rcommon.Id, rank rcommon.Rank) (nextRank rcommon.Rank, err error) {
+// GetNextRank takes trashed columns into account, see GetAndBlockMaxRank
+func (w QueryerWrap) GetNextRank(ctx context.Context, projectId rcommon.Id, rank rcommon.Rank) (nextRank rcommon.Rank, err error) {
 	const q = `
 		SELECT rank FROM columns
 		WHERE project_id = $1 AND rank > $2
 		ORDER BY rank
 		LIMIT 1
 	`
-	err = w.Q.QueryRow(context.Background(), q, projectId, rank).Scan(&nextRank)
+	err = w.Q.QueryRow(ctx, q, projectId, rank).Scan(&nextRank)
 	return nextRank, err
 }
 
-func (w QueryerWrap) GetByName(projectId rcommon.Id, name string) (rcommon.Column, error) {
+// GetAndBlockWipLimit returns column WIP limit, which is nil if column isn't limited,
+// and whether project limits are advisory. Column is blocked, so concurrent moves into it are serialized.
+func (w QueryerWrap) GetAndBlockWipLimit(ctx context.Context, columnId rcommon.Id) (limit *int, advisory bool, err error) {
+	const q = `
+		SELECT c.wip_limit, p.wip_limits_advisory
+		FROM columns c
+		JOIN projects p ON p.id = c.project_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL
+		FOR UPDATE OF c
+	`
+	err = w.Q.QueryRow(ctx, q, columnId).Scan(&limit, &advisory)
+	return limit, advisory, err
+}
+
+func (w QueryerWrap) GetByName(ctx context.Context, projectId rcommon.Id, name string) (rcommon.Column, error) {
 	c := rcommon.Column{ColumnSettableFields: rcommon.ColumnSettableFields{Name: name}}
-	const q = `SELECT id FROM columns WHERE project_id = $1 AND name = $2`
-	err := w.Q.QueryRow(context.Background(), q, projectId, name).Scan(&c.Id)
+	const q = `SELECT id FROM columns WHERE project_id = $1 AND name = $2 AND deleted_at IS NULL`
+	err := w.Q.QueryRow(ctx, q, projectId, name).Scan(&c.Id)
 	return c, err
 }
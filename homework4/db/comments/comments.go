@@ -2,37 +2,52 @@ package comments
 
 import (
 	"context"
+	"fmt"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
 	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
 )
 
 type QueryerWrap common.QueryerWrap
 
-func (w QueryerWrap) Create(taskId rcommon.Id, text string) (rcommon.Comment, error) {
-	comment := rcommon.Comment{CommentSettableFields: rcommon.CommentSettableFields{Text: text}}
-	const q = "INSERT INTO comments (task_id, text, create_dt) VALUES ($1, $2, NOW()) RETURNING id"
-	err := w.Q.QueryRow(context.Background(), q, taskId, text).Scan(&comment.Id)
+func (w QueryerWrap) Create(ctx context.Context, taskId, authorId rcommon.Id, text string) (rcommon.Comment, error) {
+	comment := rcommon.Comment{AuthorId: authorId, CommentSettableFields: rcommon.CommentSettableFields{Text: text}}
+	const q = `
+		INSERT INTO comments (task_id, author_id, text, create_dt, update_dt) VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, version, create_dt, update_dt
+	`
+	err := w.Q.QueryRow(ctx, q, taskId, authorId, text).
+		Scan(&comment.Id, &comment.Version, &comment.CreatedAt, &comment.UpdatedAt)
 	return comment, err
 }
 
-func (w QueryerWrap) Get(taskId, commentId rcommon.Id) (rcommon.Comment, error) {
+func (w QueryerWrap) Get(ctx context.Context, taskId, commentId rcommon.Id) (rcommon.Comment, error) {
 	comment := rcommon.Comment{Id: commentId}
-	const q = "SELECT text FROM comments WHERE task_id = $1 AND id = $2"
-	err := w.Q.QueryRow(context.Background(), q, taskId, commentId).Scan(&comment.Text)
+	const q = `
+		SELECT text, version, COALESCE(author_id, 0), create_dt, update_dt
+		FROM comments
+		WHERE task_id = $1 AND id = $2 AND deleted_at IS NULL
+	`
+	err := w.Q.QueryRow(ctx, q, taskId, commentId).Scan(&comment.Text, &comment.Version,
+		&comment.AuthorId, &comment.CreatedAt, &comment.UpdatedAt)
 	return comment, err
 }
 
-func (w QueryerWrap) GetMultiple(taskId rcommon.Id) ([]rcommon.Comment, error) {
+func (w QueryerWrap) GetMultiple(ctx context.Context, taskId rcommon.Id) ([]rcommon.Comment, error) {
 	comments := []rcommon.Comment{}
-	const q = "SELECT id, text FROM comments WHERE task_id = $1 ORDER BY create_dt ASC"
-	rows, err := w.Q.Query(context.Background(), q, taskId)
+	const q = `
+		SELECT id, text, COALESCE(author_id, 0), create_dt, update_dt
+		FROM comments
+		WHERE task_id = $1 AND deleted_at IS NULL
+		ORDER BY create_dt ASC
+	`
+	rows, err := w.Q.Query(ctx, q, taskId)
 	if err != nil {
 		return comments, err
 	}
 	defer rows.Close()
 	c := rcommon.Comment{}
 	for rows.Next() {
-		err := rows.Scan(&c.Id, &c.Text)
+		err := rows.Scan(&c.Id, &c.Text, &c.AuthorId, &c.CreatedAt, &c.UpdatedAt)
 		if err != nil {
 			return comments, err
 		}
@@ -41,12 +56,75 @@ func (w QueryerWrap) GetMultiple(taskId rcommon.Id) ([]rcommon.Comment, error) {
 	return comments, nil
 }
 
-func (w QueryerWrap) Update(taskId, commentId rcommon.Id, text string) error {
-	const q = "UPDATE comments SET text = $3 WHERE task_id = $1 AND id = $2"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, taskId, commentId, text))
+// Update modifies comment only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) Update(ctx context.Context, taskId, commentId rcommon.Id, text string, version rcommon.Version) error {
+	const q = `
+		UPDATE comments SET text = $3, version = version + 1
+		WHERE task_id = $1 AND id = $2 AND deleted_at IS NULL AND ($4 = 0 OR version = $4)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, commentId, text, version))
 }
 
-func (w QueryerWrap) Delete(taskId, commentId rcommon.Id) error {
-	const q = "DELETE FROM comments WHERE task_id = $1 AND id = $2"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, taskId, commentId))
+var patchableColumns = map[string]string{"text": ""}
+
+// Patch modifies only columns of given values, see Update
+func (w QueryerWrap) Patch(ctx context.Context, taskId, commentId rcommon.Id, values map[string]interface{}, version rcommon.Version) error {
+	assignments, args, err := common.BuildAssignments(patchableColumns, values, 4)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		UPDATE comments SET %v, version = version + 1
+		WHERE task_id = $1 AND id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+	`, assignments)
+	args = append([]interface{}{taskId, commentId, version}, args...)
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, args...))
+}
+
+// CreateRevision saves the current text of comment before it's updated
+func (w QueryerWrap) CreateRevision(ctx context.Context, c rcommon.Comment) error {
+	const q = "INSERT INTO comment_revisions (comment_id, text, create_dt, replace_dt) VALUES ($1, $2, $3, NOW())"
+	_, err := w.Q.Exec(ctx, q, c.Id, c.Text, c.UpdatedAt)
+	return err
+}
+
+// GetRevisions returns previous texts of comment newest first
+func (w QueryerWrap) GetRevisions(ctx context.Context, commentId rcommon.Id) ([]rcommon.CommentRevision, error) {
+	revisions := []rcommon.CommentRevision{}
+	const q = `
+		SELECT id, text, create_dt, replace_dt
+		FROM comment_revisions
+		WHERE comment_id = $1
+		ORDER BY id DESC
+	`
+	rows, err := w.Q.Query(ctx, q, commentId)
+	if err != nil {
+		return revisions, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := rcommon.CommentRevision{}
+		if err := rows.Scan(&r.Id, &r.Text, &r.CreatedAt, &r.ReplacedAt); err != nil {
+			return revisions, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// Delete moves comment to trash only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) Delete(ctx context.Context, taskId, commentId rcommon.Id, version rcommon.Version) error {
+	const q = `
+		UPDATE comments SET deleted_at = NOW(), version = version + 1
+		WHERE task_id = $1 AND id = $2 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, commentId, version))
+}
+
+func (w QueryerWrap) Restore(ctx context.Context, taskId, commentId rcommon.Id) error {
+	const q = `
+		UPDATE comments SET deleted_at = NULL, version = version + 1
+		WHERE task_id = $1 AND id = $2 AND deleted_at IS NOT NULL
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, commentId))
 }
@@ -3,8 +3,11 @@ package common
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"sort"
+	"strings"
 )
 
 type QueryerWrap struct {
@@ -14,6 +17,26 @@ type QueryerWrap struct {
 var ErrNoAffectedRows = errors.New("no affected rows")
 var ErrNoRows = pgx.ErrNoRows
 
+// TaskLabelsSubquery selects JSON array of labels attached to task aliased as t
+const TaskLabelsSubquery = `
+	COALESCE((
+		SELECT json_agg(json_build_object('id', l.id, 'name', l.name, 'color', l.color) ORDER BY l.name)
+		FROM task_labels tl
+		JOIN labels l ON l.id = tl.label_id
+		WHERE tl.task_id = t.id
+	), '[]')
+`
+
+// TaskChecklistsSubquery selects JSON object with numbers of done and all checklists items of task aliased as t
+const TaskChecklistsSubquery = `
+	(
+		SELECT json_build_object('done', count(*) FILTER (WHERE i.done), 'total', count(*))
+		FROM checklists cl
+		JOIN checklist_items i ON i.checklist_id = cl.id
+		WHERE cl.task_id = t.id
+	)
+`
+
 type Queryer interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (commandTag pgconn.CommandTag, err error)
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
@@ -32,3 +55,28 @@ func ErrorIfNoAffectedRows(ct pgconn.CommandTag, err error) error {
 	}
 	return nil
 }
+
+// BuildAssignments builds SET list of UPDATE statement from values keyed by column names,
+// columns contains all patchable columns mapped to SQL types for explicit cast, empty type means no cast.
+// Placeholders are numbered starting from firstArg, values are returned in the same order.
+func BuildAssignments(columns map[string]string, values map[string]interface{}, firstArg int) (string, []interface{}, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if _, ok := columns[name]; !ok {
+			return "", nil, fmt.Errorf("column %v cannot be patched", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assignments := make([]string, 0, len(names))
+	args := make([]interface{}, 0, len(names))
+	for i, name := range names {
+		assignment := fmt.Sprintf("%v = $%v", name, firstArg+i)
+		if sqlType := columns[name]; sqlType != "" {
+			assignment += "::" + sqlType
+		}
+		assignments = append(assignments, assignment)
+		args = append(args, values[name])
+	}
+	return strings.Join(assignments, ", "), args, nil
+}
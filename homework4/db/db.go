@@ -12,11 +12,21 @@ import (
 	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
 	"go.uber.org/zap"
 
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/archive"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/attachments"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/checklists"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/columns"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/comments"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/labels"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/members"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/projects"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/search"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/tasks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/trash"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/users"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/webhooks"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -40,9 +50,17 @@ func Init() (err error) {
 	if err != nil {
 		return fmt.Errorf("cannot connect pool: %w", err)
 	}
+	if err := registerPoolCollector(); err != nil {
+		return fmt.Errorf("cannot register pool metrics: %w", err)
+	}
 	return nil
 }
 
+// Close waits for acquired connections to be released and closes the pool
+func Close() {
+	pool.Close()
+}
+
 func ApplyMigrationsUp() error {
 	return doMigrations(func(migrations *migrate.Migrate) error {
 		return migrations.Up()
@@ -89,24 +107,110 @@ func (w queryerWrap) Comments() comments.QueryerWrap {
 	return comments.QueryerWrap(w)
 }
 
+func (w queryerWrap) Users() users.QueryerWrap {
+	return users.QueryerWrap(w)
+}
+
+func (w queryerWrap) Members() members.QueryerWrap {
+	return members.QueryerWrap(w)
+}
+
+func (w queryerWrap) Labels() labels.QueryerWrap {
+	return labels.QueryerWrap(w)
+}
+
+func (w queryerWrap) Search() search.QueryerWrap {
+	return search.QueryerWrap(w)
+}
+
+func (w queryerWrap) Activity() activity.QueryerWrap {
+	return activity.QueryerWrap(w)
+}
+
+func (w queryerWrap) Archive() archive.QueryerWrap {
+	return archive.QueryerWrap(w)
+}
+
+func (w queryerWrap) Trash() trash.QueryerWrap {
+	return trash.QueryerWrap(w)
+}
+
+func (w queryerWrap) Checklists() checklists.QueryerWrap {
+	return checklists.QueryerWrap(w)
+}
+
+func (w queryerWrap) Attachments() attachments.QueryerWrap {
+	return attachments.QueryerWrap(w)
+}
+
+func (w queryerWrap) Webhooks() webhooks.QueryerWrap {
+	return webhooks.QueryerWrap(w)
+}
+
 func QueryWithTX(tx TX) queryerWrap {
-	return queryerWrap{Q: tx}
+	return queryerWrap{Q: tracedQueryer{q: tx}}
 }
 
 func Query() queryerWrap {
-	return queryerWrap{Q: pool}
+	return queryerWrap{Q: tracedQueryer{q: pool}}
 }
 
-func Begin() (TX, error) {
-	return pool.Begin(context.Background())
+// Listen subscribes dedicated connection to notifications channel and calls handle for every notification,
+// it returns only when connection fails or ctx is done, so caller is responsible for listening again
+func Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// connection is closed instead of returning it to the pool with active LISTEN
+	defer conn.Release()
+	defer conn.Conn().Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
 }
 
-func Commit(tx TX) error {
-	return tx.Commit(context.Background())
+// Begin begins transaction, which is aborted by the first query made after ctx is done
+// or by the query running at that moment
+func Begin(ctx context.Context) (TX, error) {
+	return pool.Begin(ctx)
 }
 
-func Rollback(tx TX) {
-	if err := tx.Rollback(context.Background()); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-		logger.Zap.Error("error while rollback db transaction", zap.Error(err))
+// BeginSnapshot begins read only transaction, which sees the same snapshot of db during its lifetime
+func BeginSnapshot(ctx context.Context) (TX, error) {
+	return pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+}
+
+// EndSnapshot releases transaction begun by BeginSnapshot, it is not counted as rollback,
+// since read only transaction has nothing to commit, ctx is used only for logging
+func EndSnapshot(ctx context.Context, tx TX) {
+	err := tx.Rollback(context.Background())
+	if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		logger.Ctx(ctx).Error("error while ending db snapshot", zap.Error(err))
+	}
+}
+
+func Commit(ctx context.Context, tx TX) error {
+	return tx.Commit(ctx)
+}
+
+// Rollback doesn't depend on context of transaction, so connection is released even after ctx is done,
+// ctx is used only for logging
+func Rollback(ctx context.Context, tx TX) {
+	err := tx.Rollback(context.Background())
+	if errors.Is(err, pgx.ErrTxClosed) {
+		// transaction is already committed
+		return
+	}
+	rollbacks.Inc()
+	if err != nil {
+		logger.Ctx(ctx).Error("error while rollback db transaction", zap.Error(err))
 	}
 }
@@ -0,0 +1,92 @@
+package labels
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/jackc/pgx/v4"
+)
+
+type QueryerWrap common.QueryerWrap
+
+func (w QueryerWrap) Create(ctx context.Context, projectId rcommon.Id, fields rcommon.LabelSettableFields) (rcommon.Label, error) {
+	l := rcommon.Label{LabelSettableFields: fields}
+	const q = "INSERT INTO labels (project_id, name, color) VALUES ($1, $2, $3) RETURNING id"
+	err := w.Q.QueryRow(ctx, q, projectId, fields.Name, fields.Color).Scan(&l.Id)
+	return l, err
+}
+
+func (w QueryerWrap) Get(ctx context.Context, projectId, labelId rcommon.Id) (rcommon.Label, error) {
+	l := rcommon.Label{Id: labelId}
+	const q = "SELECT name, color FROM labels WHERE project_id = $1 AND id = $2"
+	err := w.Q.QueryRow(ctx, q, projectId, labelId).Scan(&l.Name, &l.Color)
+	return l, err
+}
+
+func (w QueryerWrap) GetByName(ctx context.Context, projectId rcommon.Id, name string) (rcommon.Label, error) {
+	l := rcommon.Label{LabelSettableFields: rcommon.LabelSettableFields{Name: name}}
+	const q = "SELECT id, color FROM labels WHERE project_id = $1 AND name = $2"
+	err := w.Q.QueryRow(ctx, q, projectId, name).Scan(&l.Id, &l.Color)
+	return l, err
+}
+
+func (w QueryerWrap) GetMultiple(ctx context.Context, projectId rcommon.Id) ([]rcommon.Label, error) {
+	const q = "SELECT id, name, color FROM labels WHERE project_id = $1 ORDER BY name"
+	rows, err := w.Q.Query(ctx, q, projectId)
+	if err != nil {
+		return []rcommon.Label{}, err
+	}
+	defer rows.Close()
+	return scanLabels(rows)
+}
+
+func (w QueryerWrap) GetByTask(ctx context.Context, taskId rcommon.Id) ([]rcommon.Label, error) {
+	const q = `
+		SELECT l.id, l.name, l.color
+		FROM task_labels tl
+		JOIN labels l ON l.id = tl.label_id
+		WHERE tl.task_id = $1
+		ORDER BY l.name
+	`
+	rows, err := w.Q.Query(ctx, q, taskId)
+	if err != nil {
+		return []rcommon.Label{}, err
+	}
+	defer rows.Close()
+	return scanLabels(rows)
+}
+
+func scanLabels(rows pgx.Rows) ([]rcommon.Label, error) {
+	labels := []rcommon.Label{}
+	l := rcommon.Label{}
+	for rows.Next() {
+		err := rows.Scan(&l.Id, &l.Name, &l.Color)
+		if err != nil {
+			return labels, err
+		}
+		labels = append(labels, l)
+	}
+	return labels, nil
+}
+
+func (w QueryerWrap) Update(ctx context.Context, projectId, labelId rcommon.Id, fields rcommon.LabelSettableFields) error {
+	const q = "UPDATE labels SET name = $3, color = $4 WHERE project_id = $1 AND id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, labelId, fields.Name, fields.Color))
+}
+
+func (w QueryerWrap) Delete(ctx context.Context, projectId, labelId rcommon.Id) error {
+	const q = "DELETE FROM labels WHERE project_id = $1 AND id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, labelId))
+}
+
+// Attach does nothing if label is already attached to task
+func (w QueryerWrap) Attach(ctx context.Context, taskId, labelId rcommon.Id) error {
+	const q = "INSERT INTO task_labels (task_id, label_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	_, err := w.Q.Exec(ctx, q, taskId, labelId)
+	return err
+}
+
+func (w QueryerWrap) Detach(ctx context.Context, taskId, labelId rcommon.Id) error {
+	const q = "DELETE FROM task_labels WHERE task_id = $1 AND label_id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, labelId))
+}
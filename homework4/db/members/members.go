@@ -0,0 +1,92 @@
+package members
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+// GetRole returns empty role if user isn't a member of existing project,
+// projects in trash are treated as non existent
+func (w QueryerWrap) GetRole(ctx context.Context, projectId, userId rcommon.Id) (role rcommon.Role, err error) {
+	const q = `
+		SELECT COALESCE(m.role, '')
+		FROM projects p
+		LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = $2
+		WHERE p.id = $1 AND p.deleted_at IS NULL
+	`
+	err = w.Q.QueryRow(ctx, q, projectId, userId).Scan(&role)
+	return role, err
+}
+
+// GetRoleInDeleted is GetRole for projects in trash
+func (w QueryerWrap) GetRoleInDeleted(ctx context.Context, projectId, userId rcommon.Id) (role rcommon.Role, err error) {
+	const q = `
+		SELECT COALESCE(m.role, '')
+		FROM projects p
+		LEFT JOIN project_members m ON m.project_id = p.id AND m.user_id = $2
+		WHERE p.id = $1 AND p.deleted_at IS NOT NULL
+	`
+	err = w.Q.QueryRow(ctx, q, projectId, userId).Scan(&role)
+	return role, err
+}
+
+func (w QueryerWrap) GetMultiple(ctx context.Context, projectId rcommon.Id) ([]rcommon.Member, error) {
+	members := []rcommon.Member{}
+	const q = `
+		SELECT u.id, u.name, m.role
+		FROM project_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.project_id = $1
+		ORDER BY u.name
+	`
+	rows, err := w.Q.Query(ctx, q, projectId)
+	if err != nil {
+		return members, err
+	}
+	defer rows.Close()
+	m := rcommon.Member{}
+	for rows.Next() {
+		err := rows.Scan(&m.Id, &m.Name, &m.Role)
+		if err != nil {
+			return members, err
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+func (w QueryerWrap) GetAndBlockOwnersIds(ctx context.Context, projectId rcommon.Id) ([]rcommon.Id, error) {
+	var ids = []rcommon.Id{}
+	const q = `SELECT user_id FROM project_members WHERE project_id = $1 AND role = $2 FOR UPDATE`
+	rows, err := w.Q.Query(ctx, q, projectId, rcommon.RoleOwner)
+	if err != nil {
+		return ids, err
+	}
+	defer rows.Close()
+	var id rcommon.Id
+	for rows.Next() {
+		err := rows.Scan(&id)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (w QueryerWrap) Set(ctx context.Context, projectId, userId rcommon.Id, role rcommon.Role) error {
+	const q = `
+		INSERT INTO project_members (project_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+	_, err := w.Q.Exec(ctx, q, projectId, userId, role)
+	return err
+}
+
+func (w QueryerWrap) Delete(ctx context.Context, projectId, userId rcommon.Id) error {
+	const q = "DELETE FROM project_members WHERE project_id = $1 AND user_id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, userId))
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var rollbacks = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "gorello_db_transaction_rollbacks_total",
+	Help: "Number of rolled back db transactions.",
+})
+
+var (
+	acquiredConnsDesc = prometheus.NewDesc("gorello_db_pool_acquired_connections",
+		"Number of connections currently acquired from pool.", nil, nil)
+	idleConnsDesc = prometheus.NewDesc("gorello_db_pool_idle_connections",
+		"Number of idle connections in pool.", nil, nil)
+	totalConnsDesc = prometheus.NewDesc("gorello_db_pool_total_connections",
+		"Number of connections in pool including constructing ones.", nil, nil)
+	maxConnsDesc = prometheus.NewDesc("gorello_db_pool_max_connections",
+		"Maximum size of pool.", nil, nil)
+	acquiresDesc = prometheus.NewDesc("gorello_db_pool_acquires_total",
+		"Number of successful acquires from pool.", nil, nil)
+	emptyAcquiresDesc = prometheus.NewDesc("gorello_db_pool_empty_acquires_total",
+		"Number of acquires which waited for connection because pool was empty.", nil, nil)
+	canceledAcquiresDesc = prometheus.NewDesc("gorello_db_pool_canceled_acquires_total",
+		"Number of acquires canceled by context.", nil, nil)
+	acquireWaitDesc = prometheus.NewDesc("gorello_db_pool_acquire_wait_seconds_total",
+		"Total time spent in successful acquires.", nil, nil)
+)
+
+// poolCollector reports pool statistics at the moment of scrape
+type poolCollector struct{}
+
+func (poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- acquiredConnsDesc
+	ch <- idleConnsDesc
+	ch <- totalConnsDesc
+	ch <- maxConnsDesc
+	ch <- acquiresDesc
+	ch <- emptyAcquiresDesc
+	ch <- canceledAcquiresDesc
+	ch <- acquireWaitDesc
+}
+
+func (poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := pool.Stat()
+	ch <- prometheus.MustNewConstMetric(acquiredConnsDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(idleConnsDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(totalConnsDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(maxConnsDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(acquiresDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(emptyAcquiresDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(canceledAcquiresDesc, prometheus.CounterValue,
+		float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(acquireWaitDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
+
+// registerPoolCollector is called after pool is connected, repeated registration is ignored
+func registerPoolCollector() error {
+	err := prometheus.Register(poolCollector{})
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+	return err
+}
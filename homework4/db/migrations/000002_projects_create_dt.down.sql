@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS create_dt;
+
+DROP INDEX IF EXISTS projects_name_id_idx;
+
+COMMIT;
\ No newline at end of file
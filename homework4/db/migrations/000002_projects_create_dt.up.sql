@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS create_dt timestamptz NOT NULL DEFAULT NOW();
+
+CREATE INDEX ON projects (name, id);
+CREATE INDEX ON projects (create_dt, id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS project_members CASCADE;
+
+DROP TABLE IF EXISTS tokens CASCADE;
+
+DROP TABLE IF EXISTS users CASCADE;
+
+COMMIT;
\ No newline at end of file
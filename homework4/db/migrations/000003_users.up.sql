@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS users (
+    id serial PRIMARY KEY,
+    name text NOT NULL,
+    password_hash text NOT NULL,
+    create_dt timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX ON users (name);
+
+CREATE TABLE IF NOT EXISTS tokens (
+    token_hash text PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    create_dt timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ON tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id integer REFERENCES projects(id) ON DELETE CASCADE,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    role text NOT NULL,
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE INDEX ON project_members (user_id);
+
+COMMIT;
\ No newline at end of file
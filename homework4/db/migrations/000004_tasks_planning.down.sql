@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS assignee_id,
+    DROP COLUMN IF EXISTS due_date,
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS estimate;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS assignee_id integer REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS due_date date,
+    ADD COLUMN IF NOT EXISTS priority text,
+    ADD COLUMN IF NOT EXISTS estimate integer;
+
+CREATE INDEX ON tasks (assignee_id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS task_labels CASCADE;
+
+DROP TABLE IF EXISTS labels CASCADE;
+
+COMMIT;
\ No newline at end of file
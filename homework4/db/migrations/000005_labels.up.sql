@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS labels (
+    id serial PRIMARY KEY,
+    project_id integer REFERENCES projects(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    color text NOT NULL
+);
+
+CREATE UNIQUE INDEX ON labels (project_id, name);
+
+CREATE TABLE IF NOT EXISTS task_labels (
+    task_id integer REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id integer REFERENCES labels(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX ON task_labels (label_id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS search;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS search;
+
+COMMIT;
\ No newline at end of file
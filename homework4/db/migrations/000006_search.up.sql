@@ -0,0 +1,15 @@
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', name), 'A') || setweight(to_tsvector('english', description), 'B')
+) STORED;
+
+CREATE INDEX ON tasks USING GIN (search);
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS search tsvector GENERATED ALWAYS AS (
+    to_tsvector('english', text)
+) STORED;
+
+CREATE INDEX ON comments USING GIN (search);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS activity (
+    id serial PRIMARY KEY,
+    project_id integer NOT NULL,
+    task_id integer,
+    actor_id integer REFERENCES users(id) ON DELETE SET NULL,
+    create_dt timestamptz NOT NULL DEFAULT NOW(),
+    resource_type text NOT NULL,
+    resource_id integer NOT NULL,
+    action text NOT NULL,
+    diff jsonb NOT NULL
+);
+
+CREATE INDEX ON activity (project_id, id);
+CREATE INDEX ON activity (task_id, id);
+
+COMMIT;
\ No newline at end of file
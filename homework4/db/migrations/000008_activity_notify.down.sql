@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS activity_notify ON activity;
+DROP FUNCTION IF EXISTS notify_activity();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,13 @@
+BEGIN;
+
+CREATE OR REPLACE FUNCTION notify_activity() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('activity', json_build_object('id', NEW.id, 'project_id', NEW.project_id)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER activity_notify AFTER INSERT ON activity
+    FOR EACH ROW EXECUTE PROCEDURE notify_activity();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS version;
+
+ALTER TABLE columns DROP COLUMN IF EXISTS version;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS version;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS version;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,19 @@
+BEGIN;
+
+DELETE FROM comments WHERE deleted_at IS NOT NULL;
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+DELETE FROM columns WHERE deleted_at IS NOT NULL;
+DELETE FROM projects WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS columns_project_id_name_idx;
+CREATE UNIQUE INDEX columns_project_id_name_idx ON columns (project_id, name);
+
+ALTER TABLE projects DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE columns DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,20 @@
+BEGIN;
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE columns ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+-- names of trashed columns can be reused
+DROP INDEX IF EXISTS columns_project_id_name_idx;
+CREATE UNIQUE INDEX columns_project_id_name_idx ON columns (project_id, name) WHERE deleted_at IS NULL;
+
+CREATE INDEX ON projects (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX ON columns (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX ON comments (deleted_at) WHERE deleted_at IS NOT NULL;
+
+COMMIT;
\ No newline at end of file
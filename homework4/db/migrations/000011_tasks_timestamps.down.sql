@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS tasks_update_dt ON tasks;
+DROP FUNCTION IF EXISTS set_task_update_dt();
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS update_dt;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS create_dt;
+
+COMMIT;
\ No newline at end of file
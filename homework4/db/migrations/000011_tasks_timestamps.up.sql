@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS create_dt timestamptz NOT NULL DEFAULT NOW();
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS update_dt timestamptz NOT NULL DEFAULT NOW();
+
+-- task is considered updated whenever its version is incremented, so rank rebalancing doesn't touch it
+CREATE OR REPLACE FUNCTION set_task_update_dt() RETURNS trigger AS $$
+BEGIN
+    NEW.update_dt = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_update_dt BEFORE UPDATE ON tasks
+    FOR EACH ROW WHEN (OLD.version IS DISTINCT FROM NEW.version) EXECUTE PROCEDURE set_task_update_dt();
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE projects DROP COLUMN wip_limits_advisory;
+ALTER TABLE columns DROP COLUMN wip_limit;
+
+COMMIT;
\ No newline at end of file
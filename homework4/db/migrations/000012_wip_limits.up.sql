@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE columns ADD COLUMN wip_limit integer;
+ALTER TABLE projects ADD COLUMN wip_limits_advisory boolean NOT NULL DEFAULT false;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS checklist_items;
+DROP TABLE IF EXISTS checklists;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS checklists (
+    id serial PRIMARY KEY,
+    task_id integer NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX ON checklists (task_id);
+
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id serial PRIMARY KEY,
+    checklist_id integer NOT NULL REFERENCES checklists(id) ON DELETE CASCADE,
+    text text NOT NULL,
+    done boolean NOT NULL DEFAULT false,
+    rank text NOT NULL,
+    version integer NOT NULL DEFAULT 1
+);
+
+CREATE INDEX ON checklist_items (checklist_id, rank);
+
+COMMIT;
\ No newline at end of file
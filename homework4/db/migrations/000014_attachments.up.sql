@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id serial PRIMARY KEY,
+    task_id integer NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    content_type text NOT NULL,
+    size bigint NOT NULL,
+    storage_key text NOT NULL UNIQUE,
+    create_dt timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ON attachments (task_id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,30 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id serial PRIMARY KEY,
+    project_id integer NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text[] NOT NULL
+);
+
+CREATE INDEX ON webhooks (project_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id serial PRIMARY KEY,
+    webhook_id integer NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    activity_id integer NOT NULL REFERENCES activity(id) ON DELETE CASCADE,
+    event text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt_dt timestamptz NOT NULL DEFAULT NOW(),
+    last_attempt_dt timestamptz,
+    response_status integer,
+    last_error text NOT NULL DEFAULT '',
+    create_dt timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ON webhook_deliveries (webhook_id, id);
+CREATE INDEX ON webhook_deliveries (next_attempt_dt) WHERE status = 'pending';
+
+COMMIT;
\ No newline at end of file
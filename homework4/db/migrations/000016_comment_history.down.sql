@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TABLE IF EXISTS comment_revisions;
+
+DROP TRIGGER IF EXISTS comments_update_dt ON comments;
+DROP FUNCTION IF EXISTS set_comment_update_dt();
+
+ALTER TABLE comments DROP COLUMN IF EXISTS update_dt;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS author_id;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,32 @@
+BEGIN;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS author_id integer REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS update_dt timestamptz;
+UPDATE comments SET update_dt = create_dt;
+ALTER TABLE comments ALTER COLUMN update_dt SET NOT NULL;
+ALTER TABLE comments ALTER COLUMN update_dt SET DEFAULT NOW();
+
+-- comment is considered updated only when its text is changed, unlike deletion and restoration
+CREATE OR REPLACE FUNCTION set_comment_update_dt() RETURNS trigger AS $$
+BEGIN
+    NEW.update_dt = NOW();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER comments_update_dt BEFORE UPDATE ON comments
+    FOR EACH ROW WHEN (OLD.text IS DISTINCT FROM NEW.text) EXECUTE PROCEDURE set_comment_update_dt();
+
+-- revision keeps text which comment had before update
+CREATE TABLE IF NOT EXISTS comment_revisions (
+    id serial PRIMARY KEY,
+    comment_id integer NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    text text NOT NULL,
+    create_dt timestamptz NOT NULL,
+    replace_dt timestamptz NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ON comment_revisions (comment_id, id);
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS activity_seq ON activity;
+DROP FUNCTION IF EXISTS set_activity_seq();
+
+DROP INDEX IF EXISTS activity_project_id_seq_idx;
+
+ALTER TABLE activity DROP COLUMN IF EXISTS seq;
+
+DROP SEQUENCE IF EXISTS activity_seq;
+
+COMMIT;
\ No newline at end of file
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- ids are assigned at insert, so transactions can commit activity out of id order,
+-- seq is assigned at commit under lock, so it grows in commit order and can be used as cursor of event stream
+ALTER TABLE activity ADD COLUMN IF NOT EXISTS seq bigint;
+
+UPDATE activity SET seq = id;
+
+CREATE SEQUENCE IF NOT EXISTS activity_seq OWNED BY activity.seq;
+
+SELECT setval('activity_seq', COALESCE(MAX(id), 0) + 1, false) FROM activity;
+
+CREATE UNIQUE INDEX IF NOT EXISTS activity_project_id_seq_idx ON activity (project_id, seq);
+
+-- lock is held until the end of commit, so transaction which gets the next seq can't become visible earlier
+CREATE OR REPLACE FUNCTION set_activity_seq() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('activity_seq'));
+    UPDATE activity SET seq = nextval('activity_seq') WHERE id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER activity_seq AFTER INSERT ON activity
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE PROCEDURE set_activity_seq();
+
+COMMIT;
\ No newline at end of file
@@ -2,44 +2,64 @@ package projects
 
 import (
 	"context"
+	"fmt"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/labels"
 	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
 	"github.com/jackc/pgx/v4"
+	"strings"
 )
 
 type QueryerWrap common.QueryerWrap
 
-func (w QueryerWrap) Create(name string, description string) (rcommon.Project, error) {
-	project := rcommon.Project{ProjectSettableFields: rcommon.ProjectSettableFields{Name: name, Description: description}}
-	const q = "INSERT INTO projects (name, description) VALUES ($1, $2) RETURNING id"
-	err := w.Q.QueryRow(context.Background(), q, name, description).Scan(&project.Id)
+func (w QueryerWrap) Create(ctx context.Context, fields rcommon.ProjectSettableFields) (rcommon.Project, error) {
+	project := rcommon.Project{ProjectSettableFields: fields}
+	const q = `
+		INSERT INTO projects (name, description, wip_limits_advisory) VALUES ($1, $2, $3)
+		RETURNING id, version
+	`
+	err := w.Q.QueryRow(ctx, q, fields.Name, fields.Description, fields.WipLimitsAdvisory).
+		Scan(&project.Id, &project.Version)
 	return project, err
 }
 
-func (w QueryerWrap) Get(projectId rcommon.Id) (rcommon.Project, error) {
+func (w QueryerWrap) Get(ctx context.Context, projectId rcommon.Id) (rcommon.Project, error) {
 	project := rcommon.Project{Id: projectId}
-	const q = "SELECT name, description FROM projects WHERE id = $1"
-	err := w.Q.QueryRow(context.Background(), q, projectId).Scan(&project.Name, &project.Description)
+	const q = `
+		SELECT name, description, wip_limits_advisory, version FROM projects
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	err := w.Q.QueryRow(ctx, q, projectId).
+		Scan(&project.Name, &project.Description, &project.WipLimitsAdvisory, &project.Version)
 	return project, err
 }
 
-func (w QueryerWrap) GetExpanded(projectId rcommon.Id) (rcommon.ProjectExpanded, error) {
+func (w QueryerWrap) GetExpanded(ctx context.Context, projectId rcommon.Id) (rcommon.ProjectExpanded, error) {
 	const q = `
-		SELECT p.Id, p.name, p.description,
-			   c.id, c.name,
-			   COALESCE(t.id, 0), COALESCE(t.name, ''), COALESCE(t.description, '')
+		SELECT p.Id, p.name, p.description, p.wip_limits_advisory,
+			   c.id, c.name, c.wip_limit,
+			   COALESCE(t.id, 0), COALESCE(t.name, ''), COALESCE(t.description, ''),
+			   t.assignee_id, to_char(t.due_date, 'YYYY-MM-DD'), t.priority, t.estimate,
+	` + common.TaskLabelsSubquery + `,
+	` + common.TaskChecklistsSubquery + `
 		FROM projects p
-		JOIN columns c ON p.id = c.project_id
-		LEFT JOIN tasks t ON c.id = t.column_id
-		WHERE p.id = $1
+		JOIN columns c ON p.id = c.project_id AND c.deleted_at IS NULL
+		LEFT JOIN tasks t ON c.id = t.column_id AND t.deleted_at IS NULL
+		WHERE p.id = $1 AND p.deleted_at IS NULL
 		ORDER BY c.rank, t.rank ASC
 	`
-	rows, err := w.Q.Query(context.Background(), q, projectId)
+	rows, err := w.Q.Query(ctx, q, projectId)
 	if err != nil {
 		return rcommon.ProjectExpanded{}, err
 	}
 	defer rows.Close()
-	return buildExpanded(rows)
+	project, err := buildExpanded(rows)
+	rows.Close()
+	if err != nil {
+		return project, err
+	}
+	project.Labels, err = labels.QueryerWrap(w).GetMultiple(ctx, projectId)
+	return project, err
 }
 
 func buildExpanded(rows pgx.Rows) (rcommon.ProjectExpanded, error) {
@@ -49,7 +69,11 @@ func buildExpanded(rows pgx.Rows) (rcommon.ProjectExpanded, error) {
 	columns := make([]rcommon.ColumnExpanded, 0, 1)
 	i := -1
 	for rows.Next() {
-		err := rows.Scan(&p.Id, &p.Name, &p.Description, &c.Id, &c.Name, &t.Id, &t.Name, &t.Description)
+		// labels are unmarshaled from json, so slice must not be reused
+		t.Labels = nil
+		err := rows.Scan(&p.Id, &p.Name, &p.Description, &p.WipLimitsAdvisory, &c.Id, &c.Name, &c.WipLimit,
+			&t.Id, &t.Name, &t.Description,
+			&t.AssigneeId, &t.DueDate, &t.Priority, &t.Estimate, &t.Labels, &t.Checklists)
 		if err != nil {
 			return rcommon.ProjectExpanded{}, err
 		}
@@ -70,31 +94,156 @@ func buildExpanded(rows pgx.Rows) (rcommon.ProjectExpanded, error) {
 	}
 }
 
-func (w QueryerWrap) GetMultiple() ([]rcommon.Project, error) {
+type SortKey struct {
+	column  string
+	sqlType string
+}
+
+var SortKeys = map[string]SortKey{
+	"name":    {column: "p.name", sqlType: "text"},
+	"id":      {column: "p.id", sqlType: "integer"},
+	"created": {column: "p.create_dt", sqlType: "timestamptz"},
+}
+
+type GetMultipleParams struct {
+	// only projects where user is a member are listed
+	MemberId     rcommon.Id
+	Limit        int
+	SortKey      SortKey
+	NameContains string
+	// keyset of the last project from the previous page, ignored if After.Id is 0
+	After Keyset
+}
+
+// Keyset is a sort key value of project converted to text paired with project id,
+// it uniquely identifies project position in the ordered list
+type Keyset struct {
+	Key string
+	Id  rcommon.Id
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetMultiple returns at most params.Limit projects and keyset of the last one
+// if there are more projects to list, otherwise keyset is nil
+func (w QueryerWrap) GetMultiple(ctx context.Context, params GetMultipleParams) ([]rcommon.Project, *Keyset, error) {
 	projects := []rcommon.Project{}
-	const q = "SELECT id, name, description FROM projects ORDER BY name"
-	rows, err := w.Q.Query(context.Background(), q)
+	k := params.SortKey
+	where := `p.deleted_at IS NULL AND p.name ILIKE '%' || $2 || '%'`
+	args := []interface{}{params.MemberId, likeEscaper.Replace(params.NameContains), params.Limit + 1}
+	if params.After.Id != 0 {
+		where += fmt.Sprintf(" AND (%v, p.id) > ($4::text::%v, $5)", k.column, k.sqlType)
+		args = append(args, params.After.Key, params.After.Id)
+	}
+	q := fmt.Sprintf(`
+		SELECT p.id, p.name, p.description, p.wip_limits_advisory, %[1]v::text
+		FROM projects p
+		JOIN project_members m ON m.project_id = p.id AND m.user_id = $1
+		WHERE %[2]v
+		ORDER BY %[1]v, p.id
+		LIMIT $3
+	`, k.column, where)
+	rows, err := w.Q.Query(ctx, q, args...)
 	if err != nil {
-		return projects, err
+		return projects, nil, err
 	}
 	defer rows.Close()
 	p := rcommon.Project{}
+	var key, lastKey string
 	for rows.Next() {
-		err := rows.Scan(&p.Id, &p.Name, &p.Description)
+		if len(projects) == params.Limit {
+			return projects, &Keyset{Key: lastKey, Id: p.Id}, nil
+		}
+		err := rows.Scan(&p.Id, &p.Name, &p.Description, &p.WipLimitsAdvisory, &key)
 		if err != nil {
-			return projects, err
+			return projects, nil, err
 		}
+		lastKey = key
 		projects = append(projects, p)
 	}
-	return projects, nil
+	return projects, nil, rows.Err()
 }
 
-func (w QueryerWrap) Update(projectId rcommon.Id, name string, description string) error {
-	const q = "UPDATE projects SET name = $2, description = $3 WHERE id = $1"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, projectId, name, description))
+// Update modifies project only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) Update(ctx context.Context, projectId rcommon.Id, fields rcommon.ProjectSettableFields, version rcommon.Version) error {
+	const q = `
+		UPDATE projects SET name = $2, description = $3, wip_limits_advisory = $4, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($5 = 0 OR version = $5)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId,
+		fields.Name, fields.Description, fields.WipLimitsAdvisory, version))
 }
 
-func (w QueryerWrap) Delete(projectId rcommon.Id) error {
-	const q = "DELETE FROM projects WHERE id = $1"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, projectId))
+var patchableColumns = map[string]string{"name": "", "description": "", "wip_limits_advisory": ""}
+
+// Patch modifies only columns of given values, see Update
+func (w QueryerWrap) Patch(ctx context.Context, projectId rcommon.Id, values map[string]interface{}, version rcommon.Version) error {
+	assignments, args, err := common.BuildAssignments(patchableColumns, values, 3)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		UPDATE projects SET %v, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`, assignments)
+	args = append([]interface{}{projectId, version}, args...)
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, args...))
+}
+
+// Delete moves project with all its columns, tasks and comments to trash, they are marked with the same
+// deletion time, which is transaction start time. Project is deleted only if its version is equal to given one,
+// 0 version matches any. It should be called within transaction.
+func (w QueryerWrap) Delete(ctx context.Context, projectId rcommon.Id, version rcommon.Version) error {
+	const q = `
+		UPDATE projects SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`
+	if err := common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, version)); err != nil {
+		return err
+	}
+	children := []string{
+		`UPDATE columns SET deleted_at = NOW() WHERE project_id = $1 AND deleted_at IS NULL`,
+		`UPDATE tasks SET deleted_at = NOW() WHERE project_id = $1 AND deleted_at IS NULL`,
+		`UPDATE comments SET deleted_at = NOW()
+		 WHERE task_id IN (SELECT id FROM tasks WHERE project_id = $1) AND deleted_at IS NULL`,
+	}
+	for _, q := range children {
+		if _, err := w.Q.Exec(ctx, q, projectId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeleted returns project from trash
+func (w QueryerWrap) GetDeleted(ctx context.Context, projectId rcommon.Id) (rcommon.Project, error) {
+	project := rcommon.Project{Id: projectId}
+	const q = "SELECT name, description, version FROM projects WHERE id = $1 AND deleted_at IS NOT NULL"
+	err := w.Q.QueryRow(ctx, q, projectId).Scan(&project.Name, &project.Description, &project.Version)
+	return project, err
+}
+
+// Restore restores project from trash together with children deleted along with it,
+// children which were deleted before project stay in trash. It should be called within transaction.
+func (w QueryerWrap) Restore(ctx context.Context, projectId rcommon.Id) error {
+	// children are restored first, while project deletion time is still available
+	children := []string{
+		`UPDATE columns SET deleted_at = NULL, version = version + 1
+		 WHERE project_id = $1 AND deleted_at = (SELECT deleted_at FROM projects WHERE id = $1)`,
+		`UPDATE comments SET deleted_at = NULL, version = version + 1
+		 WHERE task_id IN (SELECT id FROM tasks WHERE project_id = $1)
+		   AND deleted_at = (SELECT deleted_at FROM projects WHERE id = $1)`,
+		`UPDATE tasks SET deleted_at = NULL, version = version + 1
+		 WHERE project_id = $1 AND deleted_at = (SELECT deleted_at FROM projects WHERE id = $1)`,
+	}
+	for _, q := range children {
+		if _, err := w.Q.Exec(ctx, q, projectId); err != nil {
+			return err
+		}
+	}
+	const q = `
+		UPDATE projects SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId))
 }
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+// ts_headline copies source text as is, so search terms are delimited by control characters,
+// which are replaced with <b></b> only after the snippet is HTML-escaped
+const (
+	startSel        = "\x02"
+	stopSel         = "\x03"
+	headlineOptions = "MaxFragments=2, StartSel=" + startSel + ", StopSel=" + stopSel
+)
+
+var highlighter = strings.NewReplacer(startSel, "<b>", stopSel, "</b>")
+
+func snippet(headline string) string {
+	return highlighter.Replace(html.EscapeString(headline))
+}
+
+// Search returns tasks and comments matching web search query sorted by relevance,
+// only projects where user is a member are searched through,
+// if projectId is not 0 search is limited to the single project
+func (w QueryerWrap) Search(ctx context.Context, userId rcommon.Id, query string, projectId rcommon.Id, limit int) ([]rcommon.SearchHit, error) {
+	hits := []rcommon.SearchHit{}
+	const q = `
+		WITH query AS (
+			SELECT websearch_to_tsquery('english', $2) AS q
+		), visible_tasks AS (
+			SELECT t.*, p.name AS project_name, c.name AS column_name
+			FROM tasks t
+			JOIN project_members m ON m.project_id = t.project_id AND m.user_id = $1
+			JOIN projects p ON p.id = t.project_id AND p.deleted_at IS NULL
+			JOIN columns c ON c.id = t.column_id
+			WHERE t.deleted_at IS NULL AND ($3 = 0 OR t.project_id = $3)
+		)
+		SELECT 'task', t.project_id, t.project_name, t.column_id, t.column_name, t.id, NULL::integer,
+			   ts_rank(t.search, query.q) AS rank,
+			   ts_headline('english', t.name || ' ' || t.description, query.q, $5)
+		FROM visible_tasks t, query
+		WHERE t.search @@ query.q
+		UNION ALL
+		SELECT 'comment', t.project_id, t.project_name, t.column_id, t.column_name, t.id, c.id,
+			   ts_rank(c.search, query.q) AS rank,
+			   ts_headline('english', c.text, query.q, $5)
+		FROM comments c
+		JOIN visible_tasks t ON t.id = c.task_id, query
+		WHERE c.search @@ query.q AND c.deleted_at IS NULL
+		ORDER BY rank DESC
+		LIMIT $4
+	`
+	rows, err := w.Q.Query(ctx, q, userId, query, projectId, limit, headlineOptions)
+	if err != nil {
+		return hits, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		h := rcommon.SearchHit{}
+		err := rows.Scan(&h.Type, &h.ProjectId, &h.ProjectName, &h.ColumnId, &h.ColumnName,
+			&h.TaskId, &h.CommentId, &h.Rank, &h.Snippet)
+		if err != nil {
+			return hits, err
+		}
+		h.Snippet = snippet(h.Snippet)
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
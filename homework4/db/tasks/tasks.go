@@ -2,6 +2,7 @@ package tasks
 
 import (
 	"context"
+	"fmt"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
 	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
 	"github.com/jackc/pgx/v4"
@@ -9,10 +10,12 @@ import (
 
 type QueryerWrap common.QueryerWrap
 
-func (w QueryerWrap) GetAndBlockIdsByColumn(columnId rcommon.Id) ([]rcommon.Id, error) {
+// GetAndBlockIdsByColumn returns trashed tasks too, so they are moved along with others
+// when column is deleted and stay in existing column after restore
+func (w QueryerWrap) GetAndBlockIdsByColumn(ctx context.Context, columnId rcommon.Id) ([]rcommon.Id, error) {
 	var ids = []rcommon.Id{}
 	const q = `SELECT id FROM tasks WHERE column_id = $1 ORDER BY rank ASC FOR UPDATE`
-	rows, err := w.Q.Query(context.Background(), q, columnId)
+	rows, err := w.Q.Query(ctx, q, columnId)
 	if err != nil {
 		return ids, err
 	}
@@ -28,39 +31,132 @@ func (w QueryerWrap) GetAndBlockIdsByColumn(columnId rcommon.Id) ([]rcommon.Id,
 	return ids, nil
 }
 
-func (w QueryerWrap) GetAndBlockMaxRankByColumn(columnId rcommon.Id) (rank rcommon.Rank, err error) {
+// CountByColumn doesn't count trashed tasks
+func (w QueryerWrap) CountByColumn(ctx context.Context, columnId rcommon.Id) (count int, err error) {
+	const q = `SELECT count(*) FROM tasks WHERE column_id = $1 AND deleted_at IS NULL`
+	err = w.Q.QueryRow(ctx, q, columnId).Scan(&count)
+	return count, err
+}
+
+// ForEachInProject calls fn for every project task in board order while rows are being read,
+// so the whole project isn't loaded into memory. Iteration stops on the first error returned by fn.
+func (w QueryerWrap) ForEachInProject(ctx context.Context, projectId rcommon.Id, fn func(rcommon.TaskReportRow) error) error {
+	const q = `
+		SELECT t.id, c.name, row_number() OVER (PARTITION BY c.id ORDER BY t.rank),
+			   t.name, t.description, t.create_dt, t.update_dt,
+			   (SELECT count(*) FROM comments cm WHERE cm.task_id = t.id AND cm.deleted_at IS NULL)
+		FROM tasks t
+		JOIN columns c ON c.id = t.column_id
+		WHERE t.project_id = $1 AND t.deleted_at IS NULL
+		ORDER BY c.rank, t.rank
+	`
+	rows, err := w.Q.Query(ctx, q, projectId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r := rcommon.TaskReportRow{}
+		err := rows.Scan(&r.Id, &r.ColumnName, &r.Position, &r.Name, &r.Description,
+			&r.CreatedAt, &r.UpdatedAt, &r.CommentCount)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// UpdateRanks sets ranks of tasks without changing their versions, it's used for rebalancing,
+// which keeps the order of tasks
+func (w QueryerWrap) UpdateRanks(ctx context.Context, tasksIds []rcommon.Id, ranks []rcommon.Rank) error {
+	const q = `
+		UPDATE tasks t SET rank = r.rank
+		FROM unnest($1::integer[], $2::text[]) AS r(id, rank)
+		WHERE t.id = r.id
+	`
+	ids := make([]int, len(tasksIds))
+	for i, id := range tasksIds {
+		ids[i] = int(id)
+	}
+	strRanks := make([]string, len(ranks))
+	for i, rank := range ranks {
+		strRanks[i] = string(rank)
+	}
+	_, err := w.Q.Exec(ctx, q, ids, strRanks)
+	return err
+}
+
+// GetAndBlockMaxRankByColumn takes trashed tasks into account, so their ranks stay unique after restore
+func (w QueryerWrap) GetAndBlockMaxRankByColumn(ctx context.Context, columnId rcommon.Id) (rank rcommon.Rank, err error) {
 	const q = `
 		SELECT rank FROM tasks
 		WHERE column_id = $1
 		ORDER BY rank DESC
 		LIMIT 1
 	`
-	err = w.Q.QueryRow(context.Background(), q, columnId).Scan(&rank)
+	err = w.Q.QueryRow(ctx, q, columnId).Scan(&rank)
 	return rank, err
 }
 
-func (w QueryerWrap) UpdatePosition(taskId, columnId rcommon.Id, rank rcommon.Rank) error {
-	const q = "UPDATE tasks SET column_id = $2, rank = $3 WHERE id = $1"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, taskId, columnId, rank))
+// UpdatePosition moves task only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) UpdatePosition(ctx context.Context, taskId, columnId rcommon.Id, rank rcommon.Rank, version rcommon.Version) error {
+	const q = `
+		UPDATE tasks SET column_id = $2, rank = $3, version = version + 1
+		WHERE id = $1 AND ($4 = 0 OR version = $4)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, columnId, rank, version))
+}
+
+// IncrementVersion is used when task representation changes without update of task itself
+func (w QueryerWrap) IncrementVersion(ctx context.Context, taskId rcommon.Id) error {
+	const q = "UPDATE tasks SET version = version + 1 WHERE id = $1"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId))
 }
 
-func (w QueryerWrap) Get(taskId rcommon.Id) (rcommon.Task, error) {
+// IncrementVersionByLabel increments versions of all tasks with label attached,
+// it should be called before label is modified or deleted
+func (w QueryerWrap) IncrementVersionByLabel(ctx context.Context, labelId rcommon.Id) error {
+	const q = `
+		UPDATE tasks SET version = version + 1
+		WHERE id IN (SELECT task_id FROM task_labels WHERE label_id = $1)
+	`
+	_, err := w.Q.Exec(ctx, q, labelId)
+	return err
+}
+
+func (w QueryerWrap) Get(ctx context.Context, taskId rcommon.Id) (rcommon.Task, error) {
 	t := rcommon.Task{Id: taskId}
-	const q = `SELECT project_id, column_id, name, description FROM tasks WHERE id = $1`
-	err := w.Q.QueryRow(context.Background(), q, taskId).Scan(&t.ProjectId, &t.ColumnId, &t.Name, &t.Description)
+	const q = `
+		SELECT t.project_id, t.column_id, t.version, t.name, t.description,
+			   t.assignee_id, to_char(t.due_date, 'YYYY-MM-DD'), t.priority, t.estimate,
+	` + common.TaskLabelsSubquery + `,
+	` + common.TaskChecklistsSubquery + `
+		FROM tasks t WHERE t.id = $1 AND t.deleted_at IS NULL
+	`
+	err := w.Q.QueryRow(ctx, q, taskId).Scan(&t.ProjectId, &t.ColumnId, &t.Version, &t.Name,
+		&t.Description, &t.AssigneeId, &t.DueDate, &t.Priority, &t.Estimate, &t.Labels, &t.Checklists)
 	return t, err
 }
 
-func (w QueryerWrap) GetExpanded(taskId rcommon.Id) (rcommon.TaskExpanded, error) {
+// GetExpanded returns task with its comments, for task without comments timestamps of task
+// fill the row in place of comment timestamps, so they needn't be nullable
+func (w QueryerWrap) GetExpanded(ctx context.Context, taskId rcommon.Id) (rcommon.TaskExpanded, error) {
 	const q = `
-		SELECT t.id, t.project_id, t.column_id, t.name, t.description, 
-			   COALESCE(c.id, 0), COALESCE(c.text, '')
+		SELECT t.id, t.project_id, t.column_id, t.name, t.description,
+			   t.assignee_id, to_char(t.due_date, 'YYYY-MM-DD'), t.priority, t.estimate,
+			   COALESCE(c.id, 0), COALESCE(c.text, ''), COALESCE(c.author_id, 0),
+			   COALESCE(c.create_dt, t.create_dt), COALESCE(c.update_dt, t.update_dt),
+	` + common.TaskLabelsSubquery + `,
+	` + common.TaskChecklistsSubquery + `
 		FROM tasks t
-		LEFT JOIN comments c ON c.task_id = t.id
-		WHERE t.id = $1
+		LEFT JOIN comments c ON c.task_id = t.id AND c.deleted_at IS NULL
+		WHERE t.id = $1 AND t.deleted_at IS NULL
 		ORDER BY c.create_dt ASC
 	`
-	rows, err := w.Q.Query(context.Background(), q, taskId)
+	rows, err := w.Q.Query(ctx, q, taskId)
 	if err != nil {
 		return rcommon.TaskExpanded{}, err
 	}
@@ -73,7 +169,11 @@ func buildExpanded(rows pgx.Rows) (rcommon.TaskExpanded, error) {
 	c := rcommon.Comment{}
 	comments := []rcommon.Comment{}
 	for rows.Next() {
-		err := rows.Scan(&t.Id, &t.ProjectId, &t.ColumnId, &t.Name, &t.Description, &c.Id, &c.Text)
+		// labels are unmarshaled from json, so slice must not be reused
+		t.Labels = nil
+		err := rows.Scan(&t.Id, &t.ProjectId, &t.ColumnId, &t.Name, &t.Description,
+			&t.AssigneeId, &t.DueDate, &t.Priority, &t.Estimate, &c.Id, &c.Text, &c.AuthorId, &c.CreatedAt,
+			&c.UpdatedAt, &t.Labels, &t.Checklists)
 		if err != nil {
 			return rcommon.TaskExpanded{}, err
 		}
@@ -88,43 +188,107 @@ func buildExpanded(rows pgx.Rows) (rcommon.TaskExpanded, error) {
 	}
 }
 
-func (w QueryerWrap) Create(projectId, columnId rcommon.Id, name string,
-	description string, rank rcommon.Rank) (rcommon.Task, error) {
-	t := rcommon.Task{
-		ProjectId: projectId, ColumnId: columnId,
-		TaskSettableFields: rcommon.TaskSettableFields{Name: name, Description: description},
-	}
+func (w QueryerWrap) Create(ctx context.Context, projectId, columnId rcommon.Id,
+	fields rcommon.TaskSettableFields, rank rcommon.Rank) (rcommon.Task, error) {
+	t := rcommon.Task{ProjectId: projectId, ColumnId: columnId, TaskSettableFields: fields, Labels: []rcommon.Label{}}
 	const q = `
-		INSERT INTO tasks (project_id, column_id, name, description, rank) VALUES ($1, $2, $3, $4, $5)
-		RETURNING id
+		INSERT INTO tasks (project_id, column_id, name, description, assignee_id, due_date, priority, estimate, rank)
+		VALUES ($1, $2, $3, $4, $5, $6::date, $7, $8, $9)
+		RETURNING id, version
 	`
-	err := w.Q.QueryRow(context.Background(), q, projectId, columnId, name, description, rank).Scan(&t.Id)
+	err := w.Q.QueryRow(ctx, q, projectId, columnId, fields.Name, fields.Description,
+		fields.AssigneeId, fields.DueDate, fields.Priority, fields.Estimate, rank).Scan(&t.Id, &t.Version)
 	return t, err
 }
 
-func (w QueryerWrap) GetAndBlockRank(columnId, taskId rcommon.Id) (rank rcommon.Rank, err error) {
-	const q = `SELECT rank FROM tasks WHERE column_id = $1 AND id = $2 FOR UPDATE`
-	err = w.Q.QueryRow(context.Background(), q, columnId, taskId).Scan(&rank)
+func (w QueryerWrap) GetAndBlockRank(ctx context.Context, columnId, taskId rcommon.Id) (rank rcommon.Rank, err error) {
+	const q = `SELECT rank FROM tasks WHERE column_id = $1 AND id = $2 AND deleted_at IS NULL FOR UPDATE`
+	err = w.Q.QueryRow(ctx, q, columnId, taskId).Scan(&rank)
 	return rank, err
 }
 
-func (w QueryerWrap) GetNextRank(columnId rcommon.Id, rank rcommon.Rank) (nextRank rcommon.Rank, err error) {
+// GetNextRank takes trashed tasks into account, see GetAndBlockMaxRankByColumn
+func (w QueryerWrap) GetNextRank(ctx context.Context, columnId rcommon.Id, rank rcommon.Rank) (nextRank rcommon.Rank, err error) {
 	const q = `
 		SELECT rank FROM tasks
 		WHERE column_id = $1 AND rank > $2
 		ORDER BY rank
 		LIMIT 1
 	`
-	err = w.Q.QueryRow(context.Background(), q, columnId, rank).Scan(&nextRank)
+	err = w.Q.QueryRow(ctx, q, columnId, rank).Scan(&nextRank)
 	return nextRank, err
 }
 
-func (w QueryerWrap) Update(taskId rcommon.Id, name string, description string) error {
-	const q = "UPDATE tasks SET name = $2, description = $3 WHERE id = $1"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, taskId, name, description))
+// Update modifies task only if its version is equal to given one, 0 version matches any
+func (w QueryerWrap) Update(ctx context.Context, taskId rcommon.Id, fields rcommon.TaskSettableFields, version rcommon.Version) error {
+	const q = `
+		UPDATE tasks
+		SET name = $2, description = $3, assignee_id = $4, due_date = $5::date, priority = $6, estimate = $7,
+			version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($8 = 0 OR version = $8)
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, fields.Name, fields.Description,
+		fields.AssigneeId, fields.DueDate, fields.Priority, fields.Estimate, version))
+}
+
+var patchableColumns = map[string]string{
+	"name":        "",
+	"description": "",
+	"assignee_id": "",
+	"due_date":    "date",
+	"priority":    "",
+	"estimate":    "",
+}
+
+// Patch modifies only columns of given values, see Update
+func (w QueryerWrap) Patch(ctx context.Context, taskId rcommon.Id, values map[string]interface{}, version rcommon.Version) error {
+	assignments, args, err := common.BuildAssignments(patchableColumns, values, 3)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		UPDATE tasks SET %v, version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`, assignments)
+	args = append([]interface{}{taskId, version}, args...)
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, args...))
+}
+
+// Delete moves task with its comments to trash, see projects.Delete.
+// Task is deleted only if its version is equal to given one, 0 version matches any.
+func (w QueryerWrap) Delete(ctx context.Context, taskId rcommon.Id, version rcommon.Version) error {
+	const q = `
+		UPDATE tasks SET deleted_at = NOW(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`
+	if err := common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId, version)); err != nil {
+		return err
+	}
+	const qComments = `UPDATE comments SET deleted_at = NOW() WHERE task_id = $1 AND deleted_at IS NULL`
+	_, err := w.Q.Exec(ctx, qComments, taskId)
+	return err
 }
 
-func (w QueryerWrap) Delete(taskId rcommon.Id) error {
-	const q = "DELETE FROM tasks WHERE id = $1"
-	return common.ErrorIfNoAffectedRows(w.Q.Exec(context.Background(), q, taskId))
+// GetDeleted returns task from trash without labels
+func (w QueryerWrap) GetDeleted(ctx context.Context, taskId rcommon.Id) (rcommon.Task, error) {
+	t := rcommon.Task{Id: taskId}
+	const q = `SELECT project_id, column_id, name FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL`
+	err := w.Q.QueryRow(ctx, q, taskId).Scan(&t.ProjectId, &t.ColumnId, &t.Name)
+	return t, err
+}
+
+// Restore restores task from trash together with comments deleted along with it, see projects.Restore
+func (w QueryerWrap) Restore(ctx context.Context, taskId rcommon.Id) error {
+	const qComments = `
+		UPDATE comments SET deleted_at = NULL, version = version + 1
+		WHERE task_id = $1 AND deleted_at = (SELECT deleted_at FROM tasks WHERE id = $1)
+	`
+	if _, err := w.Q.Exec(ctx, qComments, taskId); err != nil {
+		return err
+	}
+	const q = `
+		UPDATE tasks SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, taskId))
 }
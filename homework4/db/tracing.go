@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/tracing"
+)
+
+// tracedQueryer starts span for every statement, arguments aren't recorded since they may contain user data
+type tracedQueryer struct {
+	q common.Queryer
+}
+
+func (t tracedQueryer) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startStatementSpan(ctx, sql)
+	ct, err := t.q.Exec(ctx, sql, args...)
+	tracing.End(span, err)
+	return ct, err
+}
+
+func (t tracedQueryer) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startStatementSpan(ctx, sql)
+	rows, err := t.q.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return rows, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t tracedQueryer) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startStatementSpan(ctx, sql)
+	return tracedRow{row: t.q.QueryRow(ctx, sql, args...), span: span}
+}
+
+func startStatementSpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	return tracing.Tracer.Start(ctx, statementName(sql), trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBStatementKey.String(sql)))
+}
+
+// statementName is the first keyword of statement, e.g. SELECT or WITH
+func statementName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return "SQL " + strings.ToUpper(fields[0])
+}
+
+// tracedRows ends span when rows are read or closed, so span covers fetching of all rows
+type tracedRows struct {
+	pgx.Rows
+	span  trace.Span
+	ended bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if !r.ended {
+		r.ended = true
+		tracing.End(r.span, r.Rows.Err())
+	}
+}
+
+// tracedRow ends span on scan, since row is read only then
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// absence of row is expected result, not failure
+		r.span.End()
+	} else {
+		tracing.End(r.span, err)
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package trash
+
+import (
+	"context"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+// Get returns trashed resources of projects where user is a member, most recently deleted first.
+// Children deleted along with their project or task are not listed separately, they are restored with it.
+// If projectId is not 0 listing is limited to the single project.
+func (w QueryerWrap) Get(ctx context.Context, userId rcommon.Id, projectId rcommon.Id, limit int) ([]rcommon.TrashItem, error) {
+	items := []rcommon.TrashItem{}
+	const q = `
+		WITH member_projects AS (
+			SELECT p.* FROM projects p
+			JOIN project_members m ON m.project_id = p.id AND m.user_id = $1
+			WHERE $2 = 0 OR p.id = $2
+		)
+		SELECT 'project', p.id, p.id, NULL::integer, p.name, p.deleted_at
+		FROM member_projects p
+		WHERE p.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'column', c.id, p.id, NULL::integer, c.name, c.deleted_at
+		FROM columns c
+		JOIN member_projects p ON p.id = c.project_id AND p.deleted_at IS NULL
+		WHERE c.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'task', t.id, p.id, NULL::integer, t.name, t.deleted_at
+		FROM tasks t
+		JOIN member_projects p ON p.id = t.project_id AND p.deleted_at IS NULL
+		WHERE t.deleted_at IS NOT NULL
+		UNION ALL
+		SELECT 'comment', c.id, p.id, t.id, left(c.text, 255), c.deleted_at
+		FROM comments c
+		JOIN tasks t ON t.id = c.task_id AND t.deleted_at IS NULL
+		JOIN member_projects p ON p.id = t.project_id AND p.deleted_at IS NULL
+		WHERE c.deleted_at IS NOT NULL
+		ORDER BY 6 DESC
+		LIMIT $3
+	`
+	rows, err := w.Q.Query(ctx, q, userId, projectId, limit)
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		i := rcommon.TrashItem{}
+		if err := rows.Scan(&i.Type, &i.Id, &i.ProjectId, &i.TaskId, &i.Name, &i.DeletedAt); err != nil {
+			return items, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// Purge permanently deletes resources which are in trash longer than retention,
+// it returns number of deleted rows. It should be called within transaction.
+func (w QueryerWrap) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	// children go first, so rows removed by cascade are counted too
+	queries := []string{
+		`DELETE FROM comments WHERE deleted_at < NOW() - $1 * INTERVAL '1 second'`,
+		`DELETE FROM tasks WHERE deleted_at < NOW() - $1 * INTERVAL '1 second'`,
+		`DELETE FROM columns WHERE deleted_at < NOW() - $1 * INTERVAL '1 second'`,
+		`DELETE FROM projects WHERE deleted_at < NOW() - $1 * INTERVAL '1 second'`,
+	}
+	var total int64
+	for _, q := range queries {
+		tag, err := w.Q.Exec(ctx, q, retention.Seconds())
+		if err != nil {
+			return total, err
+		}
+		total += tag.RowsAffected()
+	}
+	return total, nil
+}
@@ -0,0 +1,42 @@
+package users
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type QueryerWrap common.QueryerWrap
+
+func (w QueryerWrap) Create(ctx context.Context, name string, passwordHash string) (rcommon.User, error) {
+	user := rcommon.User{Name: name}
+	const q = "INSERT INTO users (name, password_hash) VALUES ($1, $2) RETURNING id"
+	err := w.Q.QueryRow(ctx, q, name, passwordHash).Scan(&user.Id)
+	return user, err
+}
+
+func (w QueryerWrap) Get(ctx context.Context, userId rcommon.Id) (rcommon.User, error) {
+	user := rcommon.User{Id: userId}
+	const q = "SELECT name FROM users WHERE id = $1"
+	err := w.Q.QueryRow(ctx, q, userId).Scan(&user.Name)
+	return user, err
+}
+
+func (w QueryerWrap) GetByName(ctx context.Context, name string) (user rcommon.User, passwordHash string, err error) {
+	user.Name = name
+	const q = "SELECT id, password_hash FROM users WHERE name = $1"
+	err = w.Q.QueryRow(ctx, q, name).Scan(&user.Id, &passwordHash)
+	return user, passwordHash, err
+}
+
+func (w QueryerWrap) CreateToken(ctx context.Context, userId rcommon.Id, tokenHash string) error {
+	const q = "INSERT INTO tokens (token_hash, user_id) VALUES ($1, $2)"
+	_, err := w.Q.Exec(ctx, q, tokenHash, userId)
+	return err
+}
+
+func (w QueryerWrap) GetIdByToken(ctx context.Context, tokenHash string) (userId rcommon.Id, err error) {
+	const q = "SELECT user_id FROM tokens WHERE token_hash = $1"
+	err = w.Q.QueryRow(ctx, q, tokenHash).Scan(&userId)
+	return userId, err
+}
@@ -0,0 +1,174 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/jackc/pgx/v4"
+)
+
+type QueryerWrap common.QueryerWrap
+
+// Due is delivery claimed for sending together with its webhook
+type Due struct {
+	Id         rcommon.Id
+	ActivityId rcommon.Id
+	Event      string
+	// number of previous attempts
+	Attempts int
+	Webhook  rcommon.Webhook
+}
+
+func (w QueryerWrap) Create(ctx context.Context, projectId rcommon.Id, fields rcommon.WebhookSettableFields) (rcommon.Webhook, error) {
+	h := rcommon.Webhook{WebhookSettableFields: fields}
+	const q = "INSERT INTO webhooks (project_id, url, secret, events) VALUES ($1, $2, $3, $4) RETURNING id"
+	err := w.Q.QueryRow(ctx, q, projectId, fields.URL, fields.Secret, fields.Events).Scan(&h.Id)
+	return h, err
+}
+
+// Get returns webhook with its secret
+func (w QueryerWrap) Get(ctx context.Context, projectId, webhookId rcommon.Id) (rcommon.Webhook, error) {
+	h := rcommon.Webhook{Id: webhookId}
+	const q = "SELECT url, secret, events FROM webhooks WHERE project_id = $1 AND id = $2"
+	err := w.Q.QueryRow(ctx, q, projectId, webhookId).Scan(&h.URL, &h.Secret, &h.Events)
+	return h, err
+}
+
+// GetMultiple returns webhooks of project without secrets
+func (w QueryerWrap) GetMultiple(ctx context.Context, projectId rcommon.Id) ([]rcommon.Webhook, error) {
+	const q = "SELECT id, url, events FROM webhooks WHERE project_id = $1 ORDER BY id"
+	rows, err := w.Q.Query(ctx, q, projectId)
+	if err != nil {
+		return []rcommon.Webhook{}, err
+	}
+	defer rows.Close()
+	webhooks := []rcommon.Webhook{}
+	for rows.Next() {
+		h := rcommon.Webhook{}
+		if err := rows.Scan(&h.Id, &h.URL, &h.Events); err != nil {
+			return webhooks, err
+		}
+		webhooks = append(webhooks, h)
+	}
+	return webhooks, rows.Err()
+}
+
+func (w QueryerWrap) Update(ctx context.Context, projectId, webhookId rcommon.Id, fields rcommon.WebhookSettableFields) error {
+	const q = "UPDATE webhooks SET url = $3, secret = $4, events = $5 WHERE project_id = $1 AND id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q,
+		projectId, webhookId, fields.URL, fields.Secret, fields.Events))
+}
+
+// Delete deletes webhook together with its deliveries
+func (w QueryerWrap) Delete(ctx context.Context, projectId, webhookId rcommon.Id) error {
+	const q = "DELETE FROM webhooks WHERE project_id = $1 AND id = $2"
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, projectId, webhookId))
+}
+
+// Enqueue creates pending deliveries of activity for all project webhooks subscribed to event
+func (w QueryerWrap) Enqueue(ctx context.Context, projectId, activityId rcommon.Id, event string) error {
+	const q = `
+		INSERT INTO webhook_deliveries (webhook_id, activity_id, event)
+		SELECT id, $2, $3
+		FROM webhooks
+		WHERE project_id = $1 AND ($3 = ANY(events) OR '*' = ANY(events))
+	`
+	_, err := w.Q.Exec(ctx, q, projectId, activityId, event)
+	return err
+}
+
+// ClaimDue returns at most limit pending deliveries whose time has come oldest first
+// and postpones their next attempt by lease, so they aren't claimed again while being sent
+func (w QueryerWrap) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Due, error) {
+	const q = `
+		UPDATE webhook_deliveries d
+		SET next_attempt_dt = NOW() + $2 * INTERVAL '1 second'
+		FROM webhooks h
+		WHERE h.id = d.webhook_id AND d.id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_dt <= NOW()
+			ORDER BY next_attempt_dt
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.activity_id, d.event, d.attempts, h.id, h.url, h.secret, h.events
+	`
+	rows, err := w.Q.Query(ctx, q, limit, lease.Seconds())
+	if err != nil {
+		return []Due{}, err
+	}
+	defer rows.Close()
+	due := []Due{}
+	for rows.Next() {
+		d := Due{}
+		err := rows.Scan(&d.Id, &d.ActivityId, &d.Event, &d.Attempts,
+			&d.Webhook.Id, &d.Webhook.URL, &d.Webhook.Secret, &d.Webhook.Events)
+		if err != nil {
+			return due, err
+		}
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+func (w QueryerWrap) MarkDelivered(ctx context.Context, deliveryId rcommon.Id, responseStatus int) error {
+	const q = `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_attempt_dt = NOW(),
+			response_status = $2, last_error = ''
+		WHERE id = $1
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q, deliveryId, responseStatus))
+}
+
+// MarkAttemptFailed schedules next attempt after retryDelay or marks delivery as failed
+// if retryDelay is 0, responseStatus is nil if no response was received
+func (w QueryerWrap) MarkAttemptFailed(ctx context.Context, deliveryId rcommon.Id, responseStatus *int, lastError string,
+	retryDelay time.Duration) error {
+	const q = `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $4::float8 > 0 THEN 'pending' ELSE 'failed' END,
+			attempts = attempts + 1, last_attempt_dt = NOW(),
+			next_attempt_dt = NOW() + $4::float8 * INTERVAL '1 second',
+			response_status = $2, last_error = $3
+		WHERE id = $1
+	`
+	return common.ErrorIfNoAffectedRows(w.Q.Exec(ctx, q,
+		deliveryId, responseStatus, lastError, retryDelay.Seconds()))
+}
+
+// GetDeliveries returns at most limit deliveries of webhook newest first
+func (w QueryerWrap) GetDeliveries(ctx context.Context, webhookId rcommon.Id, limit int) ([]rcommon.WebhookDelivery, error) {
+	const q = `
+		SELECT id, activity_id, event, status, attempts,
+			CASE WHEN status = 'pending' THEN next_attempt_dt END,
+			last_attempt_dt, response_status, last_error, create_dt
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+	rows, err := w.Q.Query(ctx, q, webhookId, limit)
+	if err != nil {
+		return []rcommon.WebhookDelivery{}, err
+	}
+	defer rows.Close()
+	return scanDeliveries(rows)
+}
+
+func scanDeliveries(rows pgx.Rows) ([]rcommon.WebhookDelivery, error) {
+	deliveries := []rcommon.WebhookDelivery{}
+	for rows.Next() {
+		d := rcommon.WebhookDelivery{}
+		err := rows.Scan(&d.Id, &d.ActivityId, &d.Event, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt)
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
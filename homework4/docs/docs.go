@@ -28,149 +28,147 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/projects": {
+        "/checklists/{checklist_id}": {
             "get": {
-                "description": "Get all projects",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get checklist with items",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "checklists"
+                ],
+                "summary": "Get checklist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get projects",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/common.Project"
-                            }
+                            "$ref": "#/definitions/common.Checklist"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create new project with single \"default\" column",
-                "consumes": [
-                    "application/json"
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
-                "produces": [
+                "description": "Update checklist",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "checklists"
                 ],
-                "summary": "Create project",
+                "summary": "Update checklist",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "description": "request body",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.ProjectSettableFields"
+                            "$ref": "#/definitions/common.ChecklistSettableFields"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/common.ProjectExpanded"
-                        },
-                        "headers": {
-                            "Location": {
-                                "type": "string",
-                                "description": "/project/1"
-                            }
-                        }
-                    }
+                    "204": {}
                 }
-            }
-        },
-        "/projects/{project_id}": {
-            "get": {
-                "description": "Get project",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Delete checklist with items permanently",
                 "tags": [
-                    "projects"
+                    "checklists"
                 ],
-                "summary": "Get project",
+                "summary": "Delete checklist",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "default": false,
-                        "description": "expand by sub-resources",
-                        "name": "expanded",
-                        "in": "query"
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/common.ProjectExpanded"
-                        }
-                    }
+                    "204": {}
                 }
             },
-            "put": {
-                "description": "Update project",
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update only fields supplied in JSON merge patch (RFC 7396)",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "projects"
+                    "checklists"
                 ],
-                "summary": "Update project",
+                "summary": "Patch checklist",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "request body",
+                        "description": "merge patch",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.ProjectSettableFields"
+                            "$ref": "#/definitions/common.ChecklistSettableFields"
                         }
-                    }
-                ],
-                "responses": {
-                    "204": {}
-                }
-            },
-            "delete": {
-                "description": "Delete project and all sub-resources",
-                "tags": [
-                    "projects"
-                ],
-                "summary": "Delete project",
-                "parameters": [
+                    },
                     {
-                        "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -178,21 +176,26 @@ var doc = `{
                 }
             }
         },
-        "/projects/{project_id}/columns": {
+        "/checklists/{checklist_id}/items": {
             "get": {
-                "description": "Get all columns within project",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all items of checklist in order",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "columns"
+                    "checklists"
                 ],
-                "summary": "Get columns",
+                "summary": "Get checklist items",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     }
@@ -203,14 +206,19 @@ var doc = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/common.Column"
+                                "$ref": "#/definitions/common.ChecklistItem"
                             }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create new column",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new item at the bottom of checklist",
                 "consumes": [
                     "application/json"
                 ],
@@ -218,14 +226,14 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "columns"
+                    "checklists"
                 ],
-                "summary": "Create column",
+                "summary": "Create checklist item",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
@@ -235,7 +243,7 @@ var doc = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.ColumnSettableFields"
+                            "$ref": "#/definitions/common.ChecklistItemSettableFields"
                         }
                     }
                 ],
@@ -243,40 +251,45 @@ var doc = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/common.Column"
+                            "$ref": "#/definitions/common.ChecklistItem"
                         },
                         "headers": {
                             "Location": {
                                 "type": "string",
-                                "description": "/project/1/columns/1"
+                                "description": "/checklists/1/items/1"
                             }
                         }
                     }
                 }
             }
         },
-        "/projects/{project_id}/columns/{column_id}": {
+        "/checklists/{checklist_id}/items/{item_id}": {
             "get": {
-                "description": "Get column",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get checklist item",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "columns"
+                    "checklists"
                 ],
-                "summary": "Get column",
+                "summary": "Get checklist item",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Column ID",
-                        "name": "column_id",
+                        "description": "Item ID",
+                        "name": "item_id",
                         "in": "path",
                         "required": true
                     }
@@ -285,32 +298,37 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Column"
+                            "$ref": "#/definitions/common.ChecklistItem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update column",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update checklist item",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "columns"
+                    "checklists"
                 ],
-                "summary": "Update column",
+                "summary": "Update checklist item",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Column ID",
-                        "name": "column_id",
+                        "description": "Item ID",
+                        "name": "item_id",
                         "in": "path",
                         "required": true
                     },
@@ -320,8 +338,14 @@ var doc = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.ColumnSettableFields"
+                            "$ref": "#/definitions/common.ChecklistItemSettableFields"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -329,65 +353,85 @@ var doc = `{
                 }
             },
             "delete": {
-                "description": "Delete column and move all tasks to the neighbor",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete checklist item permanently",
                 "tags": [
-                    "columns"
+                    "checklists"
                 ],
-                "summary": "Delete column",
+                "summary": "Delete checklist item",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Column ID",
-                        "name": "column_id",
+                        "description": "Item ID",
+                        "name": "item_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "204": {}
                 }
-            }
-        },
-        "/projects/{project_id}/columns/{column_id}/position": {
-            "put": {
-                "description": "Place column after column specified by after_column_id\nif it is grater than 0, otherwise at the beginning",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update only fields supplied in JSON merge patch (RFC 7396), e.g. {\"done\": true} completes item",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "columns"
+                    "checklists"
                 ],
-                "summary": "Update column's position",
+                "summary": "Patch checklist item",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Column ID",
-                        "name": "column_id",
+                        "description": "Item ID",
+                        "name": "item_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "request body",
+                        "description": "merge patch",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/columns.UpdatePositionRequestBody"
+                            "$ref": "#/definitions/common.ChecklistItemSettableFields"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -395,31 +439,33 @@ var doc = `{
                 }
             }
         },
-        "/projects/{project_id}/columns/{column_id}/tasks": {
-            "post": {
-                "description": "Create new task",
-                "consumes": [
-                    "application/json"
+        "/checklists/{checklist_id}/items/{item_id}/position": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
-                "produces": [
+                "description": "Place item after item specified by after_item_id if it is grater than 0, otherwise at the top",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "checklists"
                 ],
-                "summary": "Create task",
+                "summary": "Update checklist item's position",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Project ID",
-                        "name": "project_id",
+                        "description": "Checklist ID",
+                        "name": "checklist_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Column ID",
-                        "name": "column_id",
+                        "description": "Item ID",
+                        "name": "item_id",
                         "in": "path",
                         "required": true
                     },
@@ -429,49 +475,60 @@ var doc = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.TaskSettableFields"
+                            "$ref": "#/definitions/checklists.UpdateItemPositionRequestBody"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/common.Task"
-                        },
-                        "headers": {
-                            "Location": {
-                                "type": "string",
-                                "description": "/tasks/1"
-                            }
-                        }
-                    }
+                    "204": {}
                 }
             }
         },
-        "/tasks/{task_id}": {
+        "/projects": {
             "get": {
-                "description": "Get task",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get page of projects, use next_cursor from response to get the next one",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "projects"
                 ],
-                "summary": "Get task",
+                "summary": "Get projects",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "path",
-                        "required": true
+                        "default": 50,
+                        "description": "max number of projects in page",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "default": false,
-                        "description": "expand by sub-resources",
-                        "name": "expanded",
+                        "type": "string",
+                        "description": "cursor from previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "id",
+                            "created"
+                        ],
+                        "type": "string",
+                        "default": "name",
+                        "description": "sort key",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "filter by name substring",
+                        "name": "name",
                         "in": "query"
                     }
                 ],
@@ -479,95 +536,108 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.TaskExpanded"
+                            "$ref": "#/definitions/common.ProjectsPage"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update task",
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new project with single \"default\" column",
                 "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "tasks"
+                    "projects"
                 ],
-                "summary": "Update task",
+                "summary": "Create project",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "request body",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.TaskSettableFields"
+                            "$ref": "#/definitions/common.ProjectSettableFields"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {}
-                }
-            },
-            "delete": {
-                "description": "Delete task with all sub-resources",
-                "tags": [
-                    "tasks"
-                ],
-                "summary": "Delete task",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "path",
-                        "required": true
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.ProjectExpanded"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/project/1"
+                            }
+                        }
                     }
-                ],
-                "responses": {
-                    "204": {}
                 }
             }
         },
-        "/tasks/{task_id}/comments": {
-            "get": {
-                "description": "Get all comments within task",
+        "/projects/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create project from archive made by export with caller as owner. Resources get new ids,\nwhich are mapped to ids from archive in response. Assignees aren't imported.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "projects"
                 ],
-                "summary": "Get comments",
+                "summary": "Import project",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "path",
-                        "required": true
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.ProjectArchive"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/common.Comment"
+                            "$ref": "#/definitions/common.ProjectImport"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/project/1"
                             }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/projects/import/trello": {
             "post": {
-                "description": "Create new comment",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create project from Trello board JSON export with caller as owner. Open lists become columns\nand open cards become tasks in board order, labels, due dates and comments with their dates\nare imported too. Response maps Trello ids to ids of created resources.",
                 "consumes": [
                     "application/json"
                 ],
@@ -575,24 +645,17 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "projects"
                 ],
-                "summary": "Create comment",
+                "summary": "Import Trello board",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "request body",
+                        "description": "Trello board export",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.CommentSettableFields"
+                            "$ref": "#/definitions/projects.TrelloBoard"
                         }
                     }
                 ],
@@ -600,74 +663,80 @@ var doc = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/common.Comment"
+                            "$ref": "#/definitions/projects.TrelloImport"
                         },
                         "headers": {
                             "Location": {
                                 "type": "string",
-                                "description": "/tasks/1/comments/1"
+                                "description": "/project/1"
                             }
                         }
                     }
                 }
             }
         },
-        "/tasks/{task_id}/comments/{comment_id}": {
+        "/projects/{project_id}": {
             "get": {
-                "description": "Get comment",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get project",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "projects"
                 ],
-                "summary": "Get comment",
+                "summary": "Get project",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
+                        "description": "Project ID",
+                        "name": "project_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Comment ID",
-                        "name": "comment_id",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "default": false,
+                        "description": "expand by sub-resources",
+                        "name": "expanded",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/common.Comment"
+                            "$ref": "#/definitions/common.ProjectExpanded"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update comment",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update project",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "comments"
+                    "projects"
                 ],
-                "summary": "Update comment",
+                "summary": "Update project",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Comment ID",
-                        "name": "comment_id",
+                        "description": "Project ID",
+                        "name": "project_id",
                         "in": "path",
                         "required": true
                     },
@@ -677,8 +746,14 @@ var doc = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/common.CommentSettableFields"
+                            "$ref": "#/definitions/common.ProjectSettableFields"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -686,229 +761,3600 @@ var doc = `{
                 }
             },
             "delete": {
-                "description": "Delete comment",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move project and all sub-resources to trash",
                 "tags": [
-                    "comments"
+                    "projects"
                 ],
-                "summary": "Delete comment",
+                "summary": "Delete project",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
+                        "description": "Project ID",
+                        "name": "project_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Comment ID",
-                        "name": "comment_id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "204": {}
                 }
-            }
-        },
-        "/tasks/{task_id}/position": {
-            "put": {
-                "description": "Place task after task specified by after_task_id\nif it is grater than 0, otherwise at the top of specified by new_column_id column",
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields",
                 "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "tasks"
+                    "projects"
                 ],
-                "summary": "Update task's position",
+                "summary": "Patch project",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Task ID",
-                        "name": "task_id",
+                        "description": "Project ID",
+                        "name": "project_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "request body",
+                        "description": "merge patch",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/tasks.UpdatePositionRequestBody"
+                            "$ref": "#/definitions/common.ProjectSettableFields"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "204": {}
                 }
             }
-        }
-    },
-    "definitions": {
-        "columns.UpdatePositionRequestBody": {
-            "type": "object",
-            "properties": {
-                "afterColumnId": {
-                    "type": "integer"
-                }
-            }
-        },
-        "common.Column": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
         },
-        "common.ColumnExpanded": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "tasks": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/common.Task"
+        "/projects/{project_id}/activity": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get page of project activity feed, newest changes first, use next_cursor from response to get the next one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity"
+                ],
+                "summary": "Get project activity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "max number of entries in page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor from previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.ActivityPage"
+                        }
                     }
                 }
             }
         },
-        "common.ColumnSettableFields": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "common.Comment": {
+        "/projects/{project_id}/columns": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all columns within project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Get columns",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Column"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new column",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Create column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.ColumnSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Column"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/project/1/columns/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/columns/{column_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get column",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Get column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Column"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update column",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Update column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.ColumnSettableFields"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move column to trash and move all its tasks to the neighbor",
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Delete column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Patch column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "merge patch",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.ColumnSettableFields"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/columns/{column_id}/position": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Place column after column specified by after_column_id\nif it is grater than 0, otherwise at the beginning",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Update column's position",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/columns.UpdatePositionRequestBody"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/columns/{column_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore empty column from trash to its former position, tasks moved out of it stay in the neighbor",
+                "tags": [
+                    "columns"
+                ],
+                "summary": "Restore column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/columns/{column_id}/tasks": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Create task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Column ID",
+                        "name": "column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.TaskSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Task"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/tasks/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stream of server-sent events, one per committed project change. Event id is activity entry id,\nevent name is \u003cresource_type\u003e.\u003caction\u003e (e.g. task.move) and data is activity entry.\nEvents missed since Last-Event-ID are replayed on reconnect,\n\"reset\" event is sent when there are too many of them and board should be reloaded.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "activity"
+                ],
+                "summary": "Stream project events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Activity"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Export project with labels, columns, tasks and comments as versioned JSON archive,\nwhich can be imported to another instance. Trashed resources aren't exported.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Export project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.ProjectArchive"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/labels": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all labels within project",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Get labels",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Label"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new label in project's labels catalog",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Create label",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.LabelSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Label"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/project/1/labels/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/labels/{label_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get label",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Get label",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Label ID",
+                        "name": "label_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Label"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update label",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Update label",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Label ID",
+                        "name": "label_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.LabelSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete label and detach it from all tasks",
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Delete label",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Label ID",
+                        "name": "label_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all members of project with their roles",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "members"
+                ],
+                "summary": "Get members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Member"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/members/{user_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Add user to project or change role of existing member, only owner is allowed to do it",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "members"
+                ],
+                "summary": "Set member role",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.MemberSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Remove user from project, only owner is allowed to do it",
+                "tags": [
+                    "members"
+                ],
+                "summary": "Delete member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/rebalance": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rewrite internal ranks of project columns and tasks to short evenly spaced values keeping their order.\nRanks are rebalanced automatically when they grow too long, so it's only maintenance endpoint.",
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Rebalance project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore project from trash with all sub-resources deleted along with it",
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Restore project",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/tasks.csv": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get CSV report with row per task in board order: id, column name, position within column,\nname, description, number of comments, creation and last update time",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "projects"
+                ],
+                "summary": "Get tasks report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "CSV with header row",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all webhooks of project without secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Webhook"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Subscribe URL to project events, deliveries are signed with secret, which is generated if omitted\nand returned only in this response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.WebhookSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Webhook"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/projects/1/webhooks/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks/{webhook_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get webhook without secret",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Webhook"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update webhook, the current secret is kept if it's omitted",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.WebhookSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete webhook with its deliveries, pending deliveries aren't sent",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/projects/{project_id}/webhooks/{webhook_id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get deliveries of webhook newest first with their status and result of the last attempt",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Project ID",
+                        "name": "project_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "max number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.WebhookDelivery"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Full-text search through names and descriptions of tasks and comments texts\nin projects where user is a member, results are sorted by relevance",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "web search query, supports quotes, OR and - operators",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "search only within project",
+                        "name": "project_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "max number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.SearchHit"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move, update or delete multiple tasks atomically, operations are applied in order\nwithin single transaction. If any operation fails, none is applied and error message\nis prefixed with index of failed operation.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Batch task operations",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/tasks.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/tasks.BatchResult"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Get task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "expand by sub-resources",
+                        "name": "expanded",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.TaskExpanded"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update task",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.TaskSettableFields"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move task with its comments and attachments to trash",
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Delete task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Patch task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "merge patch",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.TaskSettableFields"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tasks/{task_id}/activity": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get page of task activity feed including changes of task comments and labels, newest changes first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "activity"
+                ],
+                "summary": "Get task activity",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "max number of entries in page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor from previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.ActivityPage"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}/attachments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get metadata of all task attachments",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Get attachments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Attachment"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Upload file as multipart/form-data, content type is detected from file content",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Upload attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "attached file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Attachment"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/tasks/1/attachments/1"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "file is too large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}/attachments/{attachment_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download attachment content",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Download attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "attachment content",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete attachment with its content permanently",
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Delete attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Attachment ID",
+                        "name": "attachment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tasks/{task_id}/checklists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all checklists of task with their items in order of creation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checklists"
+                ],
+                "summary": "Get checklists",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Checklist"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new empty checklist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "checklists"
+                ],
+                "summary": "Create checklist",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.ChecklistSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Checklist"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/checklists/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}/comments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all comments within task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Comment"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create new comment",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Create comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.CommentSettableFields"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Comment"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/tasks/1/comments/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}/comments/{comment_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get comment",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.Comment"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update comment, its previous text is kept in revisions",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Update comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.CommentSettableFields"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Move comment to trash",
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Delete comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update only fields supplied in JSON merge patch (RFC 7396), null resets nullable fields",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Patch comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "merge patch",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.CommentSettableFields"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tasks/{task_id}/comments/{comment_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore comment from trash",
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Restore comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tasks/{task_id}/comments/{comment_id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get previous texts of comment newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comment revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Comment ID",
+                        "name": "comment_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.CommentRevision"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}/labels": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get all labels attached to task",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Get task labels",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.Label"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{task_id}/labels/{label_id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Attach label from project's catalog to task",
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Attach label",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Label ID",
+                        "name": "label_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Detach label from task",
+                "tags": [
+                    "labels"
+                ],
+                "summary": "Detach label",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Label ID",
+                        "name": "label_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tasks/{task_id}/position": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Place task after task specified by after_task_id\nif it is grater than 0, otherwise at the top of specified by new_column_id column",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update task's position",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/tasks.UpdatePositionRequestBody"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of resource, 412 is returned if it doesn't match",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tasks/{task_id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Restore task from trash with comments deleted along with it",
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Restore task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Task ID",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {}
+                }
+            }
+        },
+        "/tokens": {
+            "post": {
+                "description": "Create access token, pass it in \"Authorization: Bearer \u003ctoken\u003e\" header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create token",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.Token"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get resources deleted in projects where user is a member, most recently deleted first.\nResources deleted along with their project or task are restored with it and aren't listed.\nTrashed resources are purged permanently after retention period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Get trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "list only trash of project",
+                        "name": "project_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "max number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/common.TrashItem"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "Register new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create user",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/common.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/common.User"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "/users/1"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{user_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/common.User"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "checklists.UpdateItemPositionRequestBody": {
+            "type": "object",
+            "properties": {
+                "after_item_id": {
+                    "description": "0 places item at the top of the checklist",
+                    "type": "integer"
+                }
+            }
+        },
+        "columns.UpdatePositionRequestBody": {
+            "type": "object",
+            "properties": {
+                "afterColumnId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.Activity": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "move",
+                        "attach",
+                        "detach",
+                        "restore",
+                        "import"
+                    ]
+                },
+                "actor_id": {
+                    "description": "0 if actor was deleted",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diff": {
+                    "type": "object",
+                    "$ref": "#/definitions/common.ActivityDiff"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "project_id": {
+                    "type": "integer"
+                },
+                "resource_id": {
+                    "type": "integer"
+                },
+                "resource_type": {
+                    "type": "string",
+                    "enum": [
+                        "project",
+                        "column",
+                        "task",
+                        "comment",
+                        "label",
+                        "member",
+                        "checklist",
+                        "checklist_item",
+                        "attachment"
+                    ]
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ActivityDiff": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                }
+            }
+        },
+        "common.ActivityPage": {
+            "type": "object",
+            "properties": {
+                "activity": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Activity"
+                    }
+                },
+                "next_cursor": {
+                    "description": "empty if there is no more activity",
+                    "type": "string"
+                }
+            }
+        },
+        "common.ArchivedColumn": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.ArchivedTask"
+                    }
+                },
+                "wip_limit": {
+                    "description": "maximum number of tasks in column, no limit if null",
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ArchivedComment": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.ArchivedProject": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "wip_limits_advisory": {
+                    "description": "if set, columns WIP limits may be exceeded, otherwise tasks aren't placed into full columns",
+                    "type": "boolean"
+                }
+            }
+        },
+        "common.ArchivedTask": {
+            "type": "object",
+            "properties": {
+                "assignee_id": {
+                    "description": "assignee must be a member of the project",
+                    "type": "integer"
+                },
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.ArchivedComment"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "type": "string",
+                    "example": "2020-12-31"
+                },
+                "estimate": {
+                    "description": "estimate in hours",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "normal",
+                        "high",
+                        "urgent"
+                    ]
+                }
+            }
+        },
+        "common.Attachment": {
+            "type": "object",
+            "properties": {
+                "content_type": {
+                    "description": "detected from content, type supplied by client is ignored",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.Checklist": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.ChecklistItem"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ChecklistItem": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.ChecklistItemSettableFields": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.ChecklistSettableFields": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.ChecklistsProgress": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "common.Column": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "wip_limit": {
+                    "description": "maximum number of tasks in column, no limit if null",
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ColumnExpanded": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Task"
+                    }
+                },
+                "wip_limit": {
+                    "description": "maximum number of tasks in column, no limit if null",
+                    "type": "integer"
+                }
+            }
+        },
+        "common.ColumnSettableFields": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "wip_limit": {
+                    "description": "maximum number of tasks in column, no limit if null",
+                    "type": "integer"
+                }
+            }
+        },
+        "common.Comment": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "description": "0 if author was deleted or comment was imported",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "time of the last text update, equal to created_at for comments which weren't updated",
+                    "type": "string"
+                }
+            }
+        },
+        "common.CommentRevision": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "time when text was written",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "replaced_at": {
+                    "description": "time when text was replaced by update",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.CommentSettableFields": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.Credentials": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.Label": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#ff0000"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.LabelSettableFields": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#ff0000"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.Member": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "editor",
+                        "viewer"
+                    ]
+                }
+            }
+        },
+        "common.MemberSettableFields": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "editor",
+                        "viewer"
+                    ]
+                }
+            }
+        },
+        "common.Project": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "wip_limits_advisory": {
+                    "description": "if set, columns WIP limits may be exceeded, otherwise tasks aren't placed into full columns",
+                    "type": "boolean"
+                }
+            }
+        },
+        "common.ProjectArchive": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "description": "columns in board order, with tasks in column order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.ArchivedColumn"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Label"
+                    }
+                },
+                "project": {
+                    "type": "object",
+                    "$ref": "#/definitions/common.ArchivedProject"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "common.ProjectExpanded": {
             "type": "object",
             "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.ColumnExpanded"
+                    }
+                },
+                "description": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "text": {
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Label"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "wip_limits_advisory": {
+                    "description": "if set, columns WIP limits may be exceeded, otherwise tasks aren't placed into full columns",
+                    "type": "boolean"
+                }
+            }
+        },
+        "common.ProjectImport": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                },
+                "comments": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                },
+                "project_id": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                }
+            }
+        },
+        "common.ProjectSettableFields": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "wip_limits_advisory": {
+                    "description": "if set, columns WIP limits may be exceeded, otherwise tasks aren't placed into full columns",
+                    "type": "boolean"
+                }
+            }
+        },
+        "common.ProjectsPage": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "empty if there are no more projects",
+                    "type": "string"
+                },
+                "projects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Project"
+                    }
+                }
+            }
+        },
+        "common.SearchHit": {
+            "type": "object",
+            "properties": {
+                "column_id": {
+                    "type": "integer"
+                },
+                "column_name": {
+                    "type": "string"
+                },
+                "comment_id": {
+                    "description": "present only for comment hits",
+                    "type": "integer"
+                },
+                "project_id": {
+                    "type": "integer"
+                },
+                "project_name": {
                     "type": "string"
-                }
-            }
-        },
-        "common.CommentSettableFields": {
-            "type": "object",
-            "properties": {
-                "text": {
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "snippet": {
+                    "description": "HTML-escaped matched text fragments with search terms wrapped in \u003cb\u003e\u003c/b\u003e",
                     "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "task",
+                        "comment"
+                    ]
                 }
             }
         },
-        "common.Project": {
+        "common.Task": {
             "type": "object",
             "properties": {
+                "assignee_id": {
+                    "description": "assignee must be a member of the project",
+                    "type": "integer"
+                },
+                "checklists": {
+                    "description": "items of all task checklists",
+                    "type": "object",
+                    "$ref": "#/definitions/common.ChecklistsProgress"
+                },
+                "column_id": {
+                    "type": "integer"
+                },
                 "description": {
                     "type": "string"
                 },
+                "due_date": {
+                    "type": "string",
+                    "example": "2020-12-31"
+                },
+                "estimate": {
+                    "description": "estimate in hours",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Label"
+                    }
+                },
                 "name": {
                     "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "normal",
+                        "high",
+                        "urgent"
+                    ]
+                },
+                "project_id": {
+                    "type": "integer"
                 }
             }
         },
-        "common.ProjectExpanded": {
+        "common.TaskExpanded": {
             "type": "object",
             "properties": {
-                "columns": {
+                "assignee_id": {
+                    "description": "assignee must be a member of the project",
+                    "type": "integer"
+                },
+                "checklists": {
+                    "description": "items of all task checklists",
+                    "type": "object",
+                    "$ref": "#/definitions/common.ChecklistsProgress"
+                },
+                "column_id": {
+                    "type": "integer"
+                },
+                "comments": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/common.ColumnExpanded"
+                        "$ref": "#/definitions/common.Comment"
                     }
                 },
                 "description": {
                     "type": "string"
                 },
+                "due_date": {
+                    "type": "string",
+                    "example": "2020-12-31"
+                },
+                "estimate": {
+                    "description": "estimate in hours",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/common.Label"
+                    }
+                },
                 "name": {
                     "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "normal",
+                        "high",
+                        "urgent"
+                    ]
+                },
+                "project_id": {
+                    "type": "integer"
                 }
             }
         },
-        "common.ProjectSettableFields": {
+        "common.TaskSettableFields": {
             "type": "object",
             "properties": {
+                "assignee_id": {
+                    "description": "assignee must be a member of the project",
+                    "type": "integer"
+                },
                 "description": {
                     "type": "string"
                 },
+                "due_date": {
+                    "type": "string",
+                    "example": "2020-12-31"
+                },
+                "estimate": {
+                    "description": "estimate in hours",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "low",
+                        "normal",
+                        "high",
+                        "urgent"
+                    ]
                 }
             }
         },
-        "common.Task": {
+        "common.Token": {
             "type": "object",
             "properties": {
-                "column_id": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "common.TrashItem": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "description": {
+                "name": {
+                    "description": "name of project, column or task, or beginning of comment text",
                     "type": "string"
                 },
+                "project_id": {
+                    "type": "integer"
+                },
+                "task_id": {
+                    "description": "present only for comments",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "project",
+                        "column",
+                        "task",
+                        "comment"
+                    ]
+                }
+            }
+        },
+        "common.User": {
+            "type": "object",
+            "properties": {
                 "id": {
                     "type": "integer"
                 },
                 "name": {
                     "type": "string"
+                }
+            }
+        },
+        "common.Webhook": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "event types in \u003cresource_type\u003e.\u003caction\u003e form, e.g. task.move, or * for all events",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "task.create",
+                        "task.move"
+                    ]
                 },
-                "project_id": {
+                "id": {
                     "type": "integer"
+                },
+                "secret": {
+                    "description": "write-only, generated if omitted on creation and kept if omitted on update",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/gorello"
                 }
             }
         },
-        "common.TaskExpanded": {
+        "common.WebhookDelivery": {
             "type": "object",
             "properties": {
-                "column_id": {
+                "activity_id": {
                     "type": "integer"
                 },
-                "comments": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_attempt_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "description": "present only for pending deliveries",
+                    "type": "string"
+                },
+                "response_status": {
+                    "description": "status of the last response, null if there were no attempts or no response was received",
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "common.WebhookSettableFields": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "description": "event types in \u003cresource_type\u003e.\u003caction\u003e form, e.g. task.move, or * for all events",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/common.Comment"
+                        "type": "string"
+                    },
+                    "example": [
+                        "task.create",
+                        "task.move"
+                    ]
+                },
+                "secret": {
+                    "description": "write-only, generated if omitted on creation and kept if omitted on update",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/gorello"
+                }
+            }
+        },
+        "projects.TrelloAction": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "card": {
+                            "type": "object",
+                            "properties": {
+                                "id": {
+                                    "type": "string"
+                                }
+                            }
+                        },
+                        "text": {
+                            "type": "string"
+                        }
                     }
                 },
-                "description": {
+                "date": {
                     "type": "string"
                 },
                 "id": {
-                    "type": "integer"
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "projects.TrelloBoard": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/projects.TrelloAction"
+                    }
+                },
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/projects.TrelloCard"
+                    }
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "labels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/projects.TrelloLabel"
+                    }
+                },
+                "lists": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/projects.TrelloList"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "projects.TrelloCard": {
+            "type": "object",
+            "properties": {
+                "closed": {
+                    "type": "boolean"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "due": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "idLabels": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "idList": {
+                    "type": "string"
                 },
                 "name": {
                     "type": "string"
                 },
+                "pos": {
+                    "type": "number"
+                }
+            }
+        },
+        "projects.TrelloImport": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                },
+                "comments": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
+                },
                 "project_id": {
                     "type": "integer"
+                },
+                "tasks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/common.Id"
+                    }
                 }
             }
         },
-        "common.TaskSettableFields": {
+        "projects.TrelloLabel": {
             "type": "object",
             "properties": {
-                "description": {
+                "color": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "projects.TrelloList": {
+            "type": "object",
+            "properties": {
+                "closed": {
+                    "type": "boolean"
+                },
+                "id": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
+                },
+                "pos": {
+                    "type": "number"
+                }
+            }
+        },
+        "tasks.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "after_task_id": {
+                    "description": "used only by move, consecutive moves with the same new_column_id and after_task_id\nplace tasks one after another in the listed order",
+                    "type": "integer"
+                },
+                "fields": {
+                    "description": "required for update",
+                    "type": "object",
+                    "$ref": "#/definitions/common.TaskSettableFields"
+                },
+                "new_column_id": {
+                    "description": "required for move",
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "move",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "expected version of task like in If-Match header, 0 matches any",
+                    "type": "integer"
+                }
+            }
+        },
+        "tasks.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/tasks.BatchOperation"
+                    }
+                }
+            }
+        },
+        "tasks.BatchResult": {
+            "type": "object",
+            "properties": {
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "move",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "task": {
+                    "type": "object",
+                    "$ref": "#/definitions/common.Task"
+                },
+                "task_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -923,6 +4369,13 @@ var doc = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
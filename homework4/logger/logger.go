@@ -1,10 +1,39 @@
 package logger
 
-import "go.uber.org/zap"
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
 
 var Zap *zap.Logger
 
+type contextKey struct{}
+
 func InitZap() (err error) {
 	Zap, err = zap.NewProduction()
 	return err
 }
+
+// WithLogger returns ctx with request-scoped logger, e.g. one which adds request id to log lines
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// Ctx returns request-scoped logger from ctx or Zap if there is no one,
+// it adds ids of current trace and span to log lines, so they can be found from trace and vice versa
+func Ctx(ctx context.Context) *zap.Logger {
+	logger, ok := ctx.Value(contextKey{}).(*zap.Logger)
+	if !ok {
+		logger = Zap
+	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return logger
+	}
+	return logger.With(
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	)
+}
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
 
 	"github.com/AndreyKlimchuk/golang-learning/homework4/api"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/attachments"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/events"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/trash"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/webhooks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/storage"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/tracing"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -16,10 +28,80 @@ func main() {
 	if err := db.Init(); err != nil {
 		log.Fatalf("can't initialize db: %v", err)
 	}
-	defer func() {
-		if err := logger.Zap.Sync(); err != nil {
-			log.Print("can't sync zap logger")
+	if len(os.Args) > 1 {
+		err := runCommand(os.Args[1:])
+		shutdown()
+		if err != nil {
+			log.Fatalf("%v: %v", os.Args[1], err)
 		}
+		return
+	}
+	if err := storage.Init(); err != nil {
+		log.Fatalf("can't initialize storage: %v", err)
+	}
+	if err := attachments.Init(); err != nil {
+		log.Fatalf("can't initialize attachments: %v", err)
+	}
+	if err := webhooks.Init(); err != nil {
+		log.Fatalf("can't initialize webhooks: %v", err)
+	}
+	if err := trash.Init(); err != nil {
+		log.Fatalf("can't initialize trash purge: %v", err)
+	}
+	if err := api.Init(); err != nil {
+		log.Fatalf("can't initialize api: %v", err)
+	}
+	if err := tracing.Init(); err != nil {
+		log.Fatalf("can't initialize tracing: %v", err)
+	}
+	err := serve()
+	shutdown()
+	if err != nil {
+		log.Fatalf("http server termination: %v", err)
+	}
+}
+
+// shutdown releases what is initialized in main, it must run before exit on error as well,
+// since log.Fatalf skips deferred calls
+func shutdown() {
+	db.Close()
+	if err := tracing.Shutdown(context.Background()); err != nil {
+		log.Printf("can't export remaining spans: %v", err)
+	}
+	if err := logger.Zap.Sync(); err != nil {
+		log.Print("can't sync zap logger")
+	}
+}
+
+// serve runs http server and background workers until SIGINT or SIGTERM,
+// then drains in-flight requests and waits for workers to stop
+func serve() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for _, run := range []func(context.Context){events.Run, webhooks.Run, trash.Run} {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(ctx)
+		}(run)
+	}
+	server := api.NewServer()
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
 	}()
-	api.StartHttpServer()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	var err error
+	select {
+	case sig := <-signals:
+		logger.Zap.Info("shutting down", zap.String("signal", sig.String()))
+		if err := api.Shutdown(server); err != nil {
+			logger.Zap.Error("http server shutdown", zap.Error(err))
+		}
+	case err = <-serverErr:
+	}
+	cancel()
+	workers.Wait()
+	return err
 }
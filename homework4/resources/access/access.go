@@ -0,0 +1,39 @@
+package access
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+// CheckProject returns Forbidden error if user has no role in project
+// which includes specified one, or NotFound error if project doesn't exist
+func CheckProject(ctx context.Context, userId, projectId common.Id, role common.Role) error {
+	userRole, err := db.Query().Members().GetRole(ctx, projectId, userId)
+	if err != nil {
+		return common.NewNotFoundOrInternalError("cannot get member role", err)
+	}
+	if !userRole.Includes(role) {
+		return common.NewForbiddenError()
+	}
+	return nil
+}
+
+// CheckTask checks user role in project which task belongs to,
+// task is returned for convenience
+func CheckTask(ctx context.Context, userId, taskId common.Id, role common.Role) (common.Task, error) {
+	task, err := db.Query().Tasks().Get(ctx, taskId)
+	if err != nil {
+		return task, common.NewNotFoundOrInternalError("cannot get task", err)
+	}
+	return task, CheckProject(ctx, userId, task.ProjectId, role)
+}
+
+// CheckChecklist checks user role in project which checklist task belongs to, see CheckTask
+func CheckChecklist(ctx context.Context, userId, checklistId common.Id, role common.Role) (common.Task, error) {
+	taskId, err := db.Query().Checklists().GetTaskId(ctx, checklistId)
+	if err != nil {
+		return common.Task{}, common.NewNotFoundOrInternalError("cannot get checklist", err)
+	}
+	return CheckTask(ctx, userId, taskId, role)
+}
@@ -0,0 +1,137 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type ReadProjectFeedRequest struct {
+	common.Caller
+	ProjectId common.Id
+	Limit     int `validate:"min=1,max=100"`
+	Cursor    string
+}
+
+type ReadTaskFeedRequest struct {
+	common.Caller
+	TaskId common.Id
+	Limit  int `validate:"min=1,max=100"`
+	Cursor string
+}
+
+// Change describes modification of single resource made by caller
+type Change struct {
+	ProjectId common.Id
+	// 0 if resource isn't task or its sub-resource
+	TaskId       common.Id
+	ResourceType common.ResourceType
+	ResourceId   common.Id
+	Action       common.Action
+	// nil for created resources
+	Before interface{}
+	// nil for deleted resources
+	After interface{}
+}
+
+// TaskPosition is recorded as state of moved task
+type TaskPosition struct {
+	ColumnId common.Id `json:"column_id"`
+	// 0 if task is placed at the top of the column or position within column is unknown
+	AfterTaskId common.Id `json:"after_task_id,omitempty"`
+}
+
+// Record must be called within the same transaction as the change itself,
+// so activity log never misses committed changes. Webhook deliveries of the change are enqueued
+// in the same transaction too and sent only after commit.
+func Record(ctx context.Context, tx db.TX, actorId common.Id, c Change) error {
+	a := common.Activity{
+		ProjectId:    c.ProjectId,
+		ActorId:      actorId,
+		ResourceType: c.ResourceType,
+		ResourceId:   c.ResourceId,
+		Action:       c.Action,
+	}
+	if c.TaskId != 0 {
+		a.TaskId = &c.TaskId
+	}
+	var err error
+	if a.Diff.Before, err = marshalState(c.Before); err != nil {
+		return common.NewInternalError("cannot marshal state before change", err)
+	}
+	if a.Diff.After, err = marshalState(c.After); err != nil {
+		return common.NewInternalError("cannot marshal state after change", err)
+	}
+	id, err := db.QueryWithTX(tx).Activity().Create(ctx, a)
+	if err != nil {
+		return common.NewInternalError("cannot record activity", err)
+	}
+	err = db.QueryWithTX(tx).Webhooks().Enqueue(ctx, c.ProjectId, id, common.EventType(c.ResourceType, c.Action))
+	return common.MaybeNewInternalError("cannot enqueue webhook deliveries", err)
+}
+
+func marshalState(state interface{}) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+func (r ReadProjectFeedRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+		return common.ActivityPage{}, err
+	}
+	beforeId, err := decodeCursor(r.Cursor)
+	if err != nil {
+		return common.ActivityPage{}, err
+	}
+	activity, err := db.Query().Activity().GetByProject(ctx, r.ProjectId, beforeId, r.Limit+1)
+	if err != nil {
+		return common.ActivityPage{}, common.NewInternalError("cannot get project activity", err)
+	}
+	return buildPage(activity, r.Limit), nil
+}
+
+func (r ReadTaskFeedRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return common.ActivityPage{}, err
+	}
+	beforeId, err := decodeCursor(r.Cursor)
+	if err != nil {
+		return common.ActivityPage{}, err
+	}
+	activity, err := db.Query().Activity().GetByTask(ctx, r.TaskId, beforeId, r.Limit+1)
+	if err != nil {
+		return common.ActivityPage{}, common.NewInternalError("cannot get task activity", err)
+	}
+	return buildPage(activity, r.Limit), nil
+}
+
+const cursorSort = "id_desc"
+
+func decodeCursor(s string) (common.Id, error) {
+	if s == "" {
+		return 0, nil
+	}
+	cursor, err := common.DecodeCursor(s)
+	if err != nil || cursor.Sort != cursorSort {
+		return 0, common.NewBadRequestError("invalid cursor")
+	}
+	return cursor.Id, nil
+}
+
+// activity must be requested with limit increased by one to find out whether next page exists
+func buildPage(activity []common.Activity, limit int) common.ActivityPage {
+	if len(activity) <= limit {
+		return common.ActivityPage{Activity: activity}
+	}
+	activity = activity[:limit]
+	last := activity[len(activity)-1]
+	return common.ActivityPage{
+		Activity:   activity,
+		NextCursor: common.EncodeCursor(common.Cursor{Sort: cursorSort, Id: last.Id}),
+	}
+}
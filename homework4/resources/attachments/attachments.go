@@ -0,0 +1,228 @@
+package attachments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/storage"
+)
+
+const defaultMaxSize = 10 << 20
+
+// MaxSize is maximum size of attachment content in bytes
+var MaxSize int64 = defaultMaxSize
+
+// sniffLength is number of bytes considered by http.DetectContentType
+const sniffLength = 512
+
+type CreateRequest struct {
+	common.Caller
+	TaskId  common.Id
+	Name    string `validate:"min=1,max=255"`
+	Size    int64  `validate:"min=0"`
+	Content io.ReadSeeker
+}
+
+type ReadRequest struct {
+	common.Caller
+	TaskId       common.Id
+	AttachmentId common.Id
+}
+
+type ReadCollectionRequest struct {
+	common.Caller
+	TaskId common.Id
+}
+
+type DeleteRequest struct {
+	common.Caller
+	TaskId       common.Id
+	AttachmentId common.Id
+}
+
+// Download is resources.Stream of attachment content
+type Download struct {
+	attachment common.Attachment
+	content    io.ReadCloser
+}
+
+// Init sets MaxSize from ATTACHMENT_MAX_SIZE env variable, 10 MiB by default
+func Init() error {
+	if value := os.Getenv("ATTACHMENT_MAX_SIZE"); value != "" {
+		size, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid ATTACHMENT_MAX_SIZE %q", value)
+		}
+		MaxSize = size
+	}
+	return nil
+}
+
+// Handle stores content before metadata is committed, so attachment is never listed without content,
+// content is deleted if metadata cannot be saved
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return common.Attachment{}, err
+	}
+	if r.Size > MaxSize {
+		return common.Attachment{}, common.NewPayloadTooLargeError(fmt.Sprintf("attachment exceeds %d bytes", MaxSize))
+	}
+	contentType, err := detectContentType(r.Content)
+	if err != nil {
+		return common.Attachment{}, common.NewInternalError("cannot detect content type", err)
+	}
+	key, err := newStorageKey(r.TaskId)
+	if err != nil {
+		return common.Attachment{}, common.NewInternalError("cannot generate storage key", err)
+	}
+	if err := storage.Blobs.Put(ctx, key, r.Content, r.Size, contentType); err != nil {
+		return common.Attachment{}, common.NewInternalError("cannot store attachment", err)
+	}
+	attachment, err := create(ctx, task, common.Attachment{
+		TaskId:      r.TaskId,
+		Name:        r.Name,
+		ContentType: contentType,
+		Size:        r.Size,
+		StorageKey:  key,
+	}, r.UserId)
+	if err != nil {
+		storage.DeleteAll(ctx, []string{key})
+		return common.Attachment{}, err
+	}
+	return attachment, nil
+}
+
+func create(ctx context.Context, task common.Task, attachment common.Attachment, userId common.Id) (common.Attachment, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return attachment, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	attachment, err = db.QueryWithTX(tx).Attachments().Create(ctx, attachment)
+	if err != nil {
+		return attachment, common.NewInternalError("cannot create attachment", err)
+	}
+	err = activity.Record(ctx, tx, userId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceAttachment,
+		ResourceId:   attachment.Id,
+		Action:       common.ActionCreate,
+		After:        attachment,
+	})
+	if err != nil {
+		return attachment, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return attachment, common.NewInternalError("cannot commit transaction", err)
+	}
+	return attachment, nil
+}
+
+// detectContentType rewinds content after its beginning is read
+func detectContentType(content io.ReadSeeker) (string, error) {
+	buf := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// newStorageKey is random, so names supplied by clients never reach storage
+func newStorageKey(taskId common.Id) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskId, hex.EncodeToString(random)), nil
+}
+
+// Handle returns Download
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return nil, err
+	}
+	attachment, err := db.Query().Attachments().Get(ctx, r.TaskId, r.AttachmentId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get attachment", err)
+	}
+	content, err := storage.Blobs.Get(ctx, attachment.StorageKey)
+	if err == storage.ErrNotFound {
+		return nil, common.NewNotFountError()
+	} else if err != nil {
+		return nil, common.NewInternalError("cannot get attachment content", err)
+	}
+	return &Download{attachment: attachment, content: content}, nil
+}
+
+func (d *Download) ContentType() string {
+	return d.attachment.ContentType
+}
+
+func (d *Download) Filename() string {
+	return d.attachment.Name
+}
+
+func (d *Download) Stream(ctx context.Context, w io.Writer) error {
+	defer d.content.Close()
+	_, err := io.Copy(w, d.content)
+	return err
+}
+
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return []common.Attachment{}, err
+	}
+	attachments, err := db.Query().Attachments().GetMultiple(ctx, r.TaskId)
+	return attachments, common.MaybeNewInternalError("cannot get attachments", err)
+}
+
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Attachments().Get(ctx, r.TaskId, r.AttachmentId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get attachment", err)
+	}
+	if err := db.QueryWithTX(tx).Attachments().Delete(ctx, r.TaskId, r.AttachmentId); err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot delete attachment", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceAttachment,
+		ResourceId:   r.AttachmentId,
+		Action:       common.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	storage.DeleteAll(ctx, []string{before.StorageKey})
+	return nil, nil
+}
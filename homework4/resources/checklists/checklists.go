@@ -0,0 +1,217 @@
+package checklists
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type CreateRequest struct {
+	common.Caller
+	TaskId common.Id
+	common.ChecklistSettableFields
+}
+
+type ReadRequest struct {
+	common.Caller
+	ChecklistId common.Id
+}
+
+type ReadCollectionRequest struct {
+	common.Caller
+	TaskId common.Id
+}
+
+type UpdateRequest struct {
+	common.Caller
+	common.Precondition
+	ChecklistId common.Id
+	common.ChecklistSettableFields
+}
+
+type PatchRequest struct {
+	common.Caller
+	common.Precondition
+	common.MergePatch
+	ChecklistId common.Id
+}
+
+type DeleteRequest struct {
+	common.Caller
+	common.Precondition
+	ChecklistId common.Id
+}
+
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return common.Checklist{}, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return common.Checklist{}, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	checklist, err := db.QueryWithTX(tx).Checklists().Create(ctx, r.TaskId, r.Name)
+	if err != nil {
+		return common.Checklist{}, common.NewInternalError("cannot create checklist", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: common.ResourceChecklist,
+		ResourceId:   checklist.Id,
+		Action:       common.ActionCreate,
+		After:        checklist,
+	})
+	if err != nil {
+		return common.Checklist{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return common.Checklist{}, common.NewInternalError("cannot commit transaction", err)
+	}
+	return checklist, nil
+}
+
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleViewer); err != nil {
+		return common.Checklist{}, err
+	}
+	checklist, err := db.Query().Checklists().GetExpanded(ctx, r.ChecklistId)
+	return checklist, common.MaybeNewNotFoundOrInternalError("cannot get checklist", err)
+}
+
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return []common.Checklist{}, err
+	}
+	checklists, err := db.Query().Checklists().GetMultiple(ctx, r.TaskId)
+	return checklists, common.MaybeNewInternalError("cannot get checklists", err)
+}
+
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Checklists().Get(ctx, r.ChecklistId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get checklist", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Checklists().Update(ctx, r.ChecklistId, r.Name, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot update checklist", err)
+	}
+	after := before
+	after.ChecklistSettableFields = r.ChecklistSettableFields
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklist,
+		ResourceId:   r.ChecklistId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r PatchRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Checklists().Get(ctx, r.ChecklistId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get checklist", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	after := before
+	values, err := r.Apply(&after.ChecklistSettableFields)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Checklists().Patch(ctx, r.ChecklistId, values, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot patch checklist", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklist,
+		ResourceId:   r.ChecklistId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// Handle deletes checklist with items permanently, unlike task it isn't moved to trash
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Checklists().GetExpanded(ctx, r.ChecklistId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get checklist", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Checklists().Delete(ctx, r.ChecklistId, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot delete checklist", err)
+	}
+	// task checklists progress changes along with items
+	if err := db.QueryWithTX(tx).Tasks().IncrementVersion(ctx, task.Id); err != nil {
+		return nil, common.NewInternalError("cannot increment task version", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklist,
+		ResourceId:   r.ChecklistId,
+		Action:       common.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,319 @@
+package checklists
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	dbCommon "github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/ranks"
+)
+
+type CreateItemRequest struct {
+	common.Caller
+	ChecklistId common.Id
+	common.ChecklistItemSettableFields
+}
+
+type ReadItemRequest struct {
+	common.Caller
+	ChecklistId common.Id
+	ItemId      common.Id
+}
+
+type ReadItemsRequest struct {
+	common.Caller
+	ChecklistId common.Id
+}
+
+type UpdateItemRequest struct {
+	common.Caller
+	common.Precondition
+	ChecklistId common.Id
+	ItemId      common.Id
+	common.ChecklistItemSettableFields
+}
+
+type PatchItemRequest struct {
+	common.Caller
+	common.Precondition
+	common.MergePatch
+	ChecklistId common.Id
+	ItemId      common.Id
+}
+
+type DeleteItemRequest struct {
+	common.Caller
+	common.Precondition
+	ChecklistId common.Id
+	ItemId      common.Id
+}
+
+type UpdateItemPositionRequest struct {
+	common.Caller
+	ChecklistId common.Id
+	ItemId      common.Id `validate:"nefield=UpdateItemPositionRequestBody.AfterItemId"`
+	UpdateItemPositionRequestBody
+}
+
+type UpdateItemPositionRequestBody struct {
+	// 0 places item at the top of the checklist
+	AfterItemId common.Id `json:"after_item_id" swaggertype:"primitive,integer"`
+}
+
+func (r CreateItemRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return common.ChecklistItem{}, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return common.ChecklistItem{}, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := touch(ctx, tx, task.Id, r.ChecklistId, true); err != nil {
+		return common.ChecklistItem{}, err
+	}
+	maxRank, err := db.QueryWithTX(tx).Checklists().GetMaxItemRank(ctx, r.ChecklistId)
+	if dbCommon.IsNoRowsError(err) {
+		maxRank = ""
+	} else if err != nil {
+		return common.ChecklistItem{}, common.NewInternalError("cannot get max rank", err)
+	}
+	maxRank = common.CalculateRankHigher(maxRank)
+	item, err := db.QueryWithTX(tx).Checklists().CreateItem(ctx, r.ChecklistId, r.ChecklistItemSettableFields, maxRank)
+	if err != nil {
+		return common.ChecklistItem{}, common.NewInternalError("cannot create checklist item", err)
+	}
+	if err := ranks.RebalanceItemsIfNeeded(ctx, tx, r.ChecklistId, maxRank); err != nil {
+		return common.ChecklistItem{}, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklistItem,
+		ResourceId:   item.Id,
+		Action:       common.ActionCreate,
+		After:        item,
+	})
+	if err != nil {
+		return common.ChecklistItem{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return common.ChecklistItem{}, common.NewInternalError("cannot commit transaction", err)
+	}
+	return item, nil
+}
+
+func (r ReadItemRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleViewer); err != nil {
+		return common.ChecklistItem{}, err
+	}
+	item, err := db.Query().Checklists().GetItem(ctx, r.ChecklistId, r.ItemId)
+	return item, common.MaybeNewNotFoundOrInternalError("cannot get checklist item", err)
+}
+
+func (r ReadItemsRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleViewer); err != nil {
+		return []common.ChecklistItem{}, err
+	}
+	items, err := db.Query().Checklists().GetItems(ctx, r.ChecklistId)
+	return items, common.MaybeNewInternalError("cannot get checklist items", err)
+}
+
+func (r UpdateItemRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Checklists().GetItem(ctx, r.ChecklistId, r.ItemId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get checklist item", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	err = db.QueryWithTX(tx).Checklists().UpdateItem(ctx, r.ChecklistId, r.ItemId, r.ChecklistItemSettableFields, r.IfMatch)
+	if err != nil {
+		return nil, r.NewWriteError("cannot update checklist item", err)
+	}
+	if err := touch(ctx, tx, task.Id, r.ChecklistId, before.Done != r.Done); err != nil {
+		return nil, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklistItem,
+		ResourceId:   r.ItemId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        common.ChecklistItem{Id: r.ItemId, ChecklistItemSettableFields: r.ChecklistItemSettableFields},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// Handle is used to toggle item completion by patch with done field
+func (r PatchItemRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Checklists().GetItem(ctx, r.ChecklistId, r.ItemId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get checklist item", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	after := before
+	values, err := r.Apply(&after.ChecklistItemSettableFields)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Checklists().PatchItem(ctx, r.ChecklistId, r.ItemId, values, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot patch checklist item", err)
+	}
+	if err := touch(ctx, tx, task.Id, r.ChecklistId, before.Done != after.Done); err != nil {
+		return nil, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklistItem,
+		ResourceId:   r.ItemId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r DeleteItemRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Checklists().GetItem(ctx, r.ChecklistId, r.ItemId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get checklist item", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Checklists().DeleteItem(ctx, r.ChecklistId, r.ItemId, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot delete checklist item", err)
+	}
+	if err := touch(ctx, tx, task.Id, r.ChecklistId, true); err != nil {
+		return nil, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklistItem,
+		ResourceId:   r.ItemId,
+		Action:       common.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r UpdateItemPositionRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckChecklist(ctx, r.UserId, r.ChecklistId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := touch(ctx, tx, task.Id, r.ChecklistId, false); err != nil {
+		return nil, err
+	}
+	var prevRank common.Rank = ""
+	if r.AfterItemId > 0 {
+		prevRank, err = db.QueryWithTX(tx).Checklists().GetItemRank(ctx, r.ChecklistId, r.AfterItemId)
+		if dbCommon.IsNoRowsError(err) {
+			return nil, common.NewConflictError("item specified by after_item_id not found in checklist")
+		} else if err != nil {
+			return nil, common.NewInternalError("cannot get previous item rank", err)
+		}
+	}
+	var newRank common.Rank
+	nextRank, err := db.QueryWithTX(tx).Checklists().GetNextItemRank(ctx, r.ChecklistId, prevRank)
+	if err == nil {
+		newRank = common.CalculateRankBetween(prevRank, nextRank)
+	} else if dbCommon.IsNoRowsError(err) {
+		newRank = common.CalculateRankHigher(prevRank)
+	} else {
+		return nil, common.NewInternalError("cannot get next item rank", err)
+	}
+	if err := db.QueryWithTX(tx).Checklists().UpdateItemRank(ctx, r.ChecklistId, r.ItemId, newRank); err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot update item rank", err)
+	}
+	if err := ranks.RebalanceItemsIfNeeded(ctx, tx, r.ChecklistId, newRank); err != nil {
+		return nil, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: common.ResourceChecklistItem,
+		ResourceId:   r.ItemId,
+		Action:       common.ActionMove,
+		After:        r.UpdateItemPositionRequestBody,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// touch increments version of checklist, which representation includes items, and blocks it,
+// so changes of its items are serialized. Task version is incremented if its checklists progress changes.
+func touch(ctx context.Context, tx db.TX, taskId, checklistId common.Id, progressChanged bool) error {
+	if err := db.QueryWithTX(tx).Checklists().IncrementVersion(ctx, checklistId); err != nil {
+		return common.NewNotFoundOrInternalError("cannot increment checklist version", err)
+	}
+	if !progressChanged {
+		return nil
+	}
+	err := db.QueryWithTX(tx).Tasks().IncrementVersion(ctx, taskId)
+	return common.MaybeNewInternalError("cannot increment task version", err)
+}
@@ -1,32 +1,51 @@
 package columns
 
 import (
+	"context"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
 	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/ranks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/wip"
 )
 
 type CreateRequest struct {
+	rcommon.Caller
 	ProjectId rcommon.Id
 	rcommon.ColumnSettableFields
 }
 
 type ReadRequest struct {
+	rcommon.Caller
 	ProjectId rcommon.Id
 	ColumnId  rcommon.Id
 }
 
 type ReadCollectionRequest struct {
+	rcommon.Caller
 	ProjectId rcommon.Id
 }
 
 type UpdateRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
 	ProjectId rcommon.Id
 	ColumnId  rcommon.Id
 	rcommon.ColumnSettableFields
 }
 
+type PatchRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
+	rcommon.MergePatch
+	ProjectId rcommon.Id
+	ColumnId  rcommon.Id
+}
+
 type UpdatePositionRequest struct {
+	rcommon.Caller
 	ProjectId rcommon.Id
 	ColumnId  rcommon.Id `validate:"nefield=UpdatePositionRequestBody.AfterColumnId"`
 	UpdatePositionRequestBody
@@ -37,93 +56,231 @@ type UpdatePositionRequestBody struct {
 }
 
 type DeleteRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
+	ProjectId rcommon.Id
+	ColumnId  rcommon.Id
+}
+
+type RestoreRequest struct {
+	rcommon.Caller
 	ProjectId rcommon.Id
 	ColumnId  rcommon.Id
 }
 
-func (r CreateRequest) Handle() (interface{}, error) {
-	_, err := db.Query().Columns().GetByName(r.ProjectId, r.Name)
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return rcommon.Column{}, err
+	}
+	_, err := db.Query().Columns().GetByName(ctx, r.ProjectId, r.Name)
 	if err == nil {
 		return rcommon.Column{}, rcommon.NewConflictError("column with same name exists in project")
 	} else if !common.IsNoRowsError(err) {
 		return rcommon.Column{}, rcommon.NewInternalError("cannot get column by name", err)
 	}
-	tx, err := db.Begin()
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return rcommon.Column{}, rcommon.NewInternalError("cannot begin transaction", err)
 	}
-	defer db.Rollback(tx)
-	maxRank, err := db.QueryWithTX(tx).Columns().GetAndBlockMaxRank(r.ProjectId)
+	defer db.Rollback(ctx, tx)
+	maxRank, err := db.QueryWithTX(tx).Columns().GetAndBlockMaxRank(ctx, r.ProjectId)
 	if err != nil {
 		return rcommon.Column{}, rcommon.NewNotFoundOrInternalError("cannot get max rank", err)
 	}
 	maxRank = rcommon.CalculateRankHigher(maxRank)
-	column, err := db.QueryWithTX(tx).Columns().Create(r.ProjectId, r.Name, maxRank)
+	column, err := db.QueryWithTX(tx).Columns().Create(ctx, r.ProjectId, r.ColumnSettableFields, maxRank)
 	if err != nil {
 		return rcommon.Column{}, rcommon.NewInternalError("cannot create column", err)
 	}
-	if err := db.Commit(tx); err != nil {
+	if err := ranks.RebalanceColumnsIfNeeded(ctx, tx, r.ProjectId, maxRank); err != nil {
+		return rcommon.Column{}, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceColumn,
+		ResourceId:   column.Id,
+		Action:       rcommon.ActionCreate,
+		After:        column.Column,
+	})
+	if err != nil {
+		return rcommon.Column{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
 		return rcommon.Column{}, rcommon.NewInternalError("cannot commit transaction", err)
 	}
 	return column, nil
 }
 
-func (r ReadRequest) Handle() (interface{}, error) {
-	column, err := db.Query().Columns().Get(r.ProjectId, r.ColumnId)
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleViewer); err != nil {
+		return rcommon.Column{}, err
+	}
+	column, err := db.Query().Columns().Get(ctx, r.ProjectId, r.ColumnId)
 	return column, rcommon.MaybeNewNotFoundOrInternalError("cannot get column", err)
 }
 
-func (r ReadCollectionRequest) Handle() (interface{}, error) {
-	columns, err := db.Query().Columns().GetMultiple(r.ProjectId)
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleViewer); err != nil {
+		return []rcommon.Column{}, err
+	}
+	columns, err := db.Query().Columns().GetMultiple(ctx, r.ProjectId)
 	return columns, rcommon.MaybeNewInternalError("cannot get columns", err)
 }
 
-func (r UpdateRequest) Handle() (interface{}, error) {
-	_, err := db.Query().Columns().GetByName(r.ProjectId, r.Name)
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	_, err := db.Query().Columns().GetByName(ctx, r.ProjectId, r.Name)
 	if err == nil {
 		return nil, rcommon.NewConflictError("column with specified name already exists in project")
 	} else if !common.IsNoRowsError(err) {
 		return nil, rcommon.NewInternalError("cannot get column by name", err)
 	}
-	err = db.Query().Columns().Update(r.ProjectId, r.ColumnId, r.Name)
-	return nil, rcommon.MaybeNewNotFoundOrInternalError("cannot update column", err)
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Columns().Get(ctx, r.ProjectId, r.ColumnId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get column", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Columns().Update(ctx, r.ProjectId, r.ColumnId, r.ColumnSettableFields, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot update column", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceColumn,
+		ResourceId:   r.ColumnId,
+		Action:       rcommon.ActionUpdate,
+		Before:       before,
+		After:        rcommon.Column{Id: r.ColumnId, ColumnSettableFields: r.ColumnSettableFields},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r PatchRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Columns().Get(ctx, r.ProjectId, r.ColumnId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get column", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	after := before
+	values, err := r.Apply(&after.ColumnSettableFields)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	if _, ok := values["name"]; ok {
+		column, err := db.QueryWithTX(tx).Columns().GetByName(ctx, r.ProjectId, after.Name)
+		if err == nil && column.Id != r.ColumnId {
+			return nil, rcommon.NewConflictError("column with specified name already exists in project")
+		} else if err != nil && !common.IsNoRowsError(err) {
+			return nil, rcommon.NewInternalError("cannot get column by name", err)
+		}
+	}
+	if err := db.QueryWithTX(tx).Columns().Patch(ctx, r.ProjectId, r.ColumnId, values, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot patch column", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceColumn,
+		ResourceId:   r.ColumnId,
+		Action:       rcommon.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
 }
 
-func (r DeleteRequest) Handle() (interface{}, error) {
-	tx, err := db.Begin()
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return nil, rcommon.NewInternalError("cannot begin transaction", err)
 	}
-	defer db.Rollback(tx)
-	rank, err := db.QueryWithTX(tx).Columns().GetAndBlockRank(r.ProjectId, r.ColumnId)
+	defer db.Rollback(ctx, tx)
+	rank, err := db.QueryWithTX(tx).Columns().GetAndBlockRank(ctx, r.ProjectId, r.ColumnId)
 	if err != nil {
 		return nil, rcommon.NewNotFoundOrInternalError("cannot get column rank", err)
 	}
-	successorColumnId, err := db.QueryWithTX(tx).Columns().GetAndBlockSuccessorColumnId(r.ProjectId, rank)
+	before, err := db.QueryWithTX(tx).Columns().Get(ctx, r.ProjectId, r.ColumnId)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot get column", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	successorColumnId, err := db.QueryWithTX(tx).Columns().GetAndBlockSuccessorColumnId(ctx, r.ProjectId, rank)
 	if common.IsNoRowsError(err) {
 		return nil, rcommon.NewConflictError("project must contains at least one column")
 	}
 	if err != nil {
 		return nil, rcommon.NewInternalError("cannot get successor column", err)
 	}
-	if err := moveTasks(tx, successorColumnId, r.ColumnId); err != nil {
+	if err := moveTasks(ctx, tx, r.UserId, r.ProjectId, successorColumnId, r.ColumnId); err != nil {
 		return nil, err
 	}
-	if err := db.QueryWithTX(tx).Columns().Delete(r.ColumnId); err != nil {
+	// column is blocked, so its version cannot be changed after check
+	if err := db.QueryWithTX(tx).Columns().Delete(ctx, r.ColumnId, 0); err != nil {
 		return nil, rcommon.NewInternalError("cannot delete column", err)
 	}
-	if err := db.Commit(tx); err != nil {
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceColumn,
+		ResourceId:   r.ColumnId,
+		Action:       rcommon.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
 		return nil, rcommon.NewInternalError("cannot commit transaction", err)
 	}
 	return nil, nil
 }
 
-func moveTasks(tx db.TX, dstColumnId rcommon.Id, srcColumnId rcommon.Id) error {
-	tasksIds, err := db.QueryWithTX(tx).Tasks().GetAndBlockIdsByColumn(srcColumnId)
+func moveTasks(ctx context.Context, tx db.TX, actorId, projectId, dstColumnId, srcColumnId rcommon.Id) error {
+	count, err := db.QueryWithTX(tx).Tasks().CountByColumn(ctx, srcColumnId)
+	if err != nil {
+		return rcommon.NewInternalError("cannot count column tasks", err)
+	}
+	if err := wip.CheckLimit(ctx, tx, dstColumnId, count); err != nil {
+		return err
+	}
+	tasksIds, err := db.QueryWithTX(tx).Tasks().GetAndBlockIdsByColumn(ctx, srcColumnId)
 	if err != nil {
 		return rcommon.NewInternalError("cannot get successor column tasks ids", err)
 	}
-	maxRank, err := db.QueryWithTX(tx).Tasks().GetAndBlockMaxRankByColumn(dstColumnId)
+	maxRank, err := db.QueryWithTX(tx).Tasks().GetAndBlockMaxRankByColumn(ctx, dstColumnId)
 	if common.IsNoRowsError(err) {
 		maxRank = ""
 	} else if err != nil {
@@ -131,22 +288,37 @@ func moveTasks(tx db.TX, dstColumnId rcommon.Id, srcColumnId rcommon.Id) error {
 	}
 	for _, taskId := range tasksIds {
 		maxRank = rcommon.CalculateRankHigher(maxRank)
-		if err := db.QueryWithTX(tx).Tasks().UpdatePosition(taskId, dstColumnId, maxRank); err != nil {
+		if err := db.QueryWithTX(tx).Tasks().UpdatePosition(ctx, taskId, dstColumnId, maxRank, 0); err != nil {
 			return rcommon.NewInternalError("cannot update task position", err)
 		}
+		err := activity.Record(ctx, tx, actorId, activity.Change{
+			ProjectId:    projectId,
+			TaskId:       taskId,
+			ResourceType: rcommon.ResourceTask,
+			ResourceId:   taskId,
+			Action:       rcommon.ActionMove,
+			Before:       activity.TaskPosition{ColumnId: srcColumnId},
+			After:        activity.TaskPosition{ColumnId: dstColumnId},
+		})
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+	return ranks.RebalanceTasksIfNeeded(ctx, tx, dstColumnId, maxRank)
 }
 
-func (r UpdatePositionRequest) Handle() (interface{}, error) {
-	tx, err := db.Begin()
+func (r UpdatePositionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return nil, rcommon.NewInternalError("cannot begin transaction", err)
 	}
-	defer db.Rollback(tx)
+	defer db.Rollback(ctx, tx)
 	var prevRank rcommon.Rank = ""
 	if r.AfterColumnId > 0 {
-		prevRank, err = db.QueryWithTX(tx).Columns().GetAndBlockRank(r.ProjectId, r.AfterColumnId)
+		prevRank, err = db.QueryWithTX(tx).Columns().GetAndBlockRank(ctx, r.ProjectId, r.AfterColumnId)
 		if common.IsNoRowsError(err) {
 			return nil, rcommon.NewConflictError("column specified by after_column_id doesn't exists in project")
 		}
@@ -155,7 +327,7 @@ func (r UpdatePositionRequest) Handle() (interface{}, error) {
 		}
 	}
 	var newRank rcommon.Rank
-	nextRank, err := db.QueryWithTX(tx).Columns().GetNextRank(r.ProjectId, prevRank)
+	nextRank, err := db.QueryWithTX(tx).Columns().GetNextRank(ctx, r.ProjectId, prevRank)
 	if err == nil {
 		newRank = rcommon.CalculateRankBetween(prevRank, nextRank)
 	} else if common.IsNoRowsError(err) {
@@ -163,11 +335,62 @@ func (r UpdatePositionRequest) Handle() (interface{}, error) {
 	} else if err != nil {
 		return nil, rcommon.NewInternalError("cannot get next column rank", err)
 	}
-	err = db.QueryWithTX(tx).Columns().UpdateRank(r.ProjectId, r.ColumnId, newRank)
+	err = db.QueryWithTX(tx).Columns().UpdateRank(ctx, r.ProjectId, r.ColumnId, newRank)
 	if err != nil {
 		return nil, rcommon.NewNotFoundOrInternalError("cannot update column rank", err)
 	}
-	if err := db.Commit(tx); err != nil {
+	if err := ranks.RebalanceColumnsIfNeeded(ctx, tx, r.ProjectId, newRank); err != nil {
+		return nil, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceColumn,
+		ResourceId:   r.ColumnId,
+		Action:       rcommon.ActionMove,
+		After:        r.UpdatePositionRequestBody,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// Handle restores column to its former position, tasks moved out of column on deletion stay where they are
+func (r RestoreRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	column, err := db.QueryWithTX(tx).Columns().GetDeleted(ctx, r.ProjectId, r.ColumnId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get column", err)
+	}
+	if _, err := db.QueryWithTX(tx).Columns().GetByName(ctx, r.ProjectId, column.Name); err == nil {
+		return nil, rcommon.NewConflictError("column with same name exists in project")
+	} else if !common.IsNoRowsError(err) {
+		return nil, rcommon.NewInternalError("cannot get column by name", err)
+	}
+	if err := db.QueryWithTX(tx).Columns().Restore(ctx, r.ProjectId, r.ColumnId); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot restore column", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceColumn,
+		ResourceId:   r.ColumnId,
+		Action:       rcommon.ActionRestore,
+		After:        column,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
 		return nil, rcommon.NewInternalError("cannot commit transaction", err)
 	}
 	return nil, nil
@@ -1,60 +1,295 @@
 package comments
 
 import (
+	"context"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
 )
 
 type CreateRequest struct {
+	common.Caller
 	TaskId common.Id
 	common.CommentSettableFields
 }
 
 type ReadRequest struct {
+	common.Caller
 	TaskId    common.Id
 	CommentId common.Id
 }
 
 type ReadCollectionRequest struct {
+	common.Caller
 	TaskId common.Id
 }
 
 type UpdateRequest struct {
+	common.Caller
+	common.Precondition
 	TaskId    common.Id
 	CommentId common.Id
 	common.CommentSettableFields
 }
 
+type PatchRequest struct {
+	common.Caller
+	common.Precondition
+	common.MergePatch
+	TaskId    common.Id
+	CommentId common.Id
+}
+
 type DeleteRequest struct {
+	common.Caller
+	common.Precondition
 	TaskId    common.Id
 	CommentId common.Id
 }
 
-func (r CreateRequest) Handle() (interface{}, error) {
-	_, err := db.Query().Tasks().Get(r.TaskId)
+type ReadRevisionsRequest struct {
+	common.Caller
+	TaskId    common.Id
+	CommentId common.Id
+}
+
+type RestoreRequest struct {
+	common.Caller
+	TaskId    common.Id
+	CommentId common.Id
+}
+
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return common.Comment{}, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return common.Comment{}, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	comment, err := db.QueryWithTX(tx).Comments().Create(ctx, r.TaskId, r.UserId, r.Text)
 	if err != nil {
-		return common.Comment{}, common.NewNotFoundOrInternalError("cannot get task", err)
+		return common.Comment{}, common.NewInternalError("cannot create comment", err)
 	}
-	comment, err := db.Query().Comments().Create(r.TaskId, r.Text)
-	return comment, common.MaybeNewInternalError("cannot create comment", err)
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: common.ResourceComment,
+		ResourceId:   comment.Id,
+		Action:       common.ActionCreate,
+		After:        comment,
+	})
+	if err != nil {
+		return common.Comment{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return common.Comment{}, common.NewInternalError("cannot commit transaction", err)
+	}
+	return comment, nil
 }
 
-func (r ReadRequest) Handle() (interface{}, error) {
-	comment, err := db.Query().Comments().Get(r.TaskId, r.CommentId)
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return common.Comment{}, err
+	}
+	comment, err := db.Query().Comments().Get(ctx, r.TaskId, r.CommentId)
 	return comment, common.MaybeNewNotFoundOrInternalError("cannot get comment", err)
 }
 
-func (r ReadCollectionRequest) Handle() (interface{}, error) {
-	comments, err := db.Query().Comments().GetMultiple(r.TaskId)
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return []common.Comment{}, err
+	}
+	comments, err := db.Query().Comments().GetMultiple(ctx, r.TaskId)
 	return comments, common.MaybeNewInternalError("cannot read comments", err)
 }
 
-func (r UpdateRequest) Handle() (interface{}, error) {
-	err := db.Query().Comments().Update(r.TaskId, r.CommentId, r.Text)
-	return nil, common.MaybeNewNotFoundOrInternalError("cannot update comment", err)
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Comments().Get(ctx, r.TaskId, r.CommentId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get comment", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := saveRevision(ctx, tx, before, r.Text); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Comments().Update(ctx, r.TaskId, r.CommentId, r.Text, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot update comment", err)
+	}
+	// comment is read again, since updated_at is set by db
+	after, err := db.QueryWithTX(tx).Comments().Get(ctx, r.TaskId, r.CommentId)
+	if err != nil {
+		return nil, common.NewInternalError("cannot get comment", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: common.ResourceComment,
+		ResourceId:   r.CommentId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r PatchRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Comments().Get(ctx, r.TaskId, r.CommentId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get comment", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	patched := before
+	values, err := r.Apply(&patched.CommentSettableFields)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	if err := saveRevision(ctx, tx, before, patched.Text); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Comments().Patch(ctx, r.TaskId, r.CommentId, values, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot patch comment", err)
+	}
+	after, err := db.QueryWithTX(tx).Comments().Get(ctx, r.TaskId, r.CommentId)
+	if err != nil {
+		return nil, common.NewInternalError("cannot get comment", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: common.ResourceComment,
+		ResourceId:   r.CommentId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// saveRevision keeps the current text of comment unless it isn't changed by update
+func saveRevision(ctx context.Context, tx db.TX, before common.Comment, text string) error {
+	if before.Text == text {
+		return nil
+	}
+	err := db.QueryWithTX(tx).Comments().CreateRevision(ctx, before)
+	return common.MaybeNewInternalError("cannot save comment revision", err)
+}
+
+func (r ReadRevisionsRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleViewer); err != nil {
+		return []common.CommentRevision{}, err
+	}
+	if _, err := db.Query().Comments().Get(ctx, r.TaskId, r.CommentId); err != nil {
+		return []common.CommentRevision{}, common.NewNotFoundOrInternalError("cannot get comment", err)
+	}
+	revisions, err := db.Query().Comments().GetRevisions(ctx, r.CommentId)
+	return revisions, common.MaybeNewInternalError("cannot get comment revisions", err)
 }
 
-func (r DeleteRequest) Handle() (interface{}, error) {
-	err := db.Query().Comments().Delete(r.TaskId, r.CommentId)
-	return nil, common.MaybeNewNotFoundOrInternalError("cannot delete comment", err)
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Comments().Get(ctx, r.TaskId, r.CommentId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get comment", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Comments().Delete(ctx, r.TaskId, r.CommentId, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot delete comment", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: common.ResourceComment,
+		ResourceId:   r.CommentId,
+		Action:       common.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r RestoreRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, common.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := db.QueryWithTX(tx).Comments().Restore(ctx, r.TaskId, r.CommentId); err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot restore comment", err)
+	}
+	after, err := db.QueryWithTX(tx).Comments().Get(ctx, r.TaskId, r.CommentId)
+	if err != nil {
+		return nil, common.NewInternalError("cannot get comment", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: common.ResourceComment,
+		ResourceId:   r.CommentId,
+		Action:       common.ActionRestore,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
 }
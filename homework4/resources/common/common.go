@@ -1,27 +1,94 @@
 package common
 
+import (
+	"encoding/json"
+	"time"
+
+	dbCommon "github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
 type Id int
 type Rank string
 
+// Version is incremented on every modification of resource, it's exposed to clients as ETag
+type Version int
+
 const DefaultColumnName string = "default"
 
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleOwner  Role = "owner"
+)
+
+var roleLevels = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// Precondition is embedded into requests which modify versioned resource
+type Precondition struct {
+	// version from If-Match header, 0 if any version matches
+	IfMatch Version `json:"-"`
+}
+
+// Caller is embedded into requests which are handled on behalf of authenticated user
+type Caller struct {
+	UserId Id `json:"-"`
+}
+
+type User struct {
+	Id   Id     `json:"id"`
+	Name string `json:"name"`
+}
+
+type Credentials struct {
+	Name     string `json:"name" validate:"min=1,max=255"`
+	Password string `json:"password" validate:"min=8,max=72"`
+}
+
+type Token struct {
+	Token string `json:"token"`
+}
+
+type Member struct {
+	User
+	MemberSettableFields
+}
+
+type MemberSettableFields struct {
+	Role Role `json:"role" validate:"oneof=owner editor viewer" swaggertype:"string" enums:"owner,editor,viewer"`
+}
+
 type Project struct {
-	Id Id `json:"id"`
+	Id      Id      `json:"id"`
+	Version Version `json:"-"`
 	ProjectSettableFields
 }
 
 type ProjectExpanded struct {
 	Project
 	Columns []ColumnExpanded `json:"columns"`
+	Labels  []Label          `json:"labels"`
 }
 
 type ProjectSettableFields struct {
 	Name        string `json:"name" validate:"min=1,max=500"`
 	Description string `json:"description" validate:"min=0,max=1000"`
+	// if set, columns WIP limits may be exceeded, otherwise tasks aren't placed into full columns
+	WipLimitsAdvisory bool `json:"wip_limits_advisory"`
+}
+
+type ProjectsPage struct {
+	Projects []Project `json:"projects"`
+	// empty if there are no more projects
+	NextCursor string `json:"next_cursor"`
 }
 
 type Column struct {
-	Id Id `json:"id"`
+	Id      Id      `json:"id"`
+	Version Version `json:"-"`
 	ColumnSettableFields
 }
 
@@ -32,13 +99,20 @@ type ColumnExpanded struct {
 
 type ColumnSettableFields struct {
 	Name string `json:"name" validate:"min=1,max=255"`
+	// maximum number of tasks in column, no limit if null
+	WipLimit *int `json:"wip_limit" validate:"omitempty,min=1,max=10000" swaggertype:"primitive,integer"`
 }
 
 type Task struct {
 	ProjectId Id `json:"project_id"`
 	ColumnId  Id `json:"column_id"`
 	Id        Id `json:"id"`
+	// also incremented on position change and labels change
+	Version Version `json:"-"`
 	TaskSettableFields
+	Labels []Label `json:"labels"`
+	// items of all task checklists
+	Checklists ChecklistsProgress `json:"checklists"`
 }
 
 type TaskExpanded struct {
@@ -49,17 +123,326 @@ type TaskExpanded struct {
 type TaskSettableFields struct {
 	Name        string `json:"name" validate:"min=1,max=500"`
 	Description string `json:"description" validate:"min=0,max=5000"`
+	// assignee must be a member of the project
+	AssigneeId *Id     `json:"assignee_id" swaggertype:"primitive,integer"`
+	DueDate    *string `json:"due_date" validate:"omitempty,datetime=2006-01-02" example:"2020-12-31"`
+	Priority   *string `json:"priority" validate:"omitempty,oneof=low normal high urgent" enums:"low,normal,high,urgent"`
+	// estimate in hours
+	Estimate *int `json:"estimate" validate:"omitempty,min=0,max=10000"`
 }
 
-type Comment struct {
+type ChecklistsProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type Checklist struct {
+	Id     Id `json:"id"`
+	TaskId Id `json:"task_id"`
+	// also incremented on change of items
+	Version Version `json:"-"`
+	ChecklistSettableFields
+	Items []ChecklistItem `json:"items"`
+}
+
+type ChecklistSettableFields struct {
+	Name string `json:"name" validate:"min=1,max=255"`
+}
+
+type ChecklistItem struct {
+	Id      Id      `json:"id"`
+	Version Version `json:"-"`
+	ChecklistItemSettableFields
+}
+
+type ChecklistItemSettableFields struct {
+	Text string `json:"text" validate:"min=1,max=1000"`
+	Done bool   `json:"done"`
+}
+
+type Attachment struct {
+	Id     Id     `json:"id"`
+	TaskId Id     `json:"task_id"`
+	Name   string `json:"name"`
+	// detected from content, type supplied by client is ignored
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+	StorageKey  string    `json:"-"`
+}
+
+type Webhook struct {
+	Id Id `json:"id"`
+	WebhookSettableFields
+}
+
+type WebhookSettableFields struct {
+	URL string `json:"url" validate:"url,max=2048" example:"https://example.com/hooks/gorello"`
+	// event types in <resource_type>.<action> form, e.g. task.move, or * for all events
+	Events []string `json:"events" validate:"min=1,max=100,dive,min=1,max=100" example:"task.create,task.move"`
+	// write-only, generated if omitted on creation and kept if omitted on update
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16,max=255"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	Id         Id             `json:"id"`
+	ActivityId Id             `json:"activity_id"`
+	Event      string         `json:"event"`
+	Status     DeliveryStatus `json:"status" swaggertype:"string" enums:"pending,delivered,failed"`
+	Attempts   int            `json:"attempts"`
+	// present only for pending deliveries
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	// status of the last response, null if there were no attempts or no response was received
+	ResponseStatus *int      `json:"response_status"`
+	LastError      string    `json:"last_error"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type Label struct {
 	Id Id `json:"id"`
+	LabelSettableFields
+}
+
+type LabelSettableFields struct {
+	Name  string `json:"name" validate:"min=1,max=255"`
+	Color string `json:"color" validate:"hexcolor" example:"#ff0000"`
+}
+
+type Comment struct {
+	Id      Id      `json:"id"`
+	Version Version `json:"-"`
 	CommentSettableFields
+	// 0 if author was deleted or comment was imported
+	AuthorId  Id        `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// time of the last text update, equal to created_at for comments which weren't updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommentRevision is text which comment had before update
+type CommentRevision struct {
+	Id   Id     `json:"id"`
+	Text string `json:"text"`
+	// time when text was written
+	CreatedAt time.Time `json:"created_at"`
+	// time when text was replaced by update
+	ReplacedAt time.Time `json:"replaced_at"`
 }
 
 type CommentSettableFields struct {
 	Text string `json:"text" validate:"min=1,max=5000"`
 }
 
+type ResourceType string
+
+const (
+	ResourceProject       ResourceType = "project"
+	ResourceColumn        ResourceType = "column"
+	ResourceTask          ResourceType = "task"
+	ResourceComment       ResourceType = "comment"
+	ResourceLabel         ResourceType = "label"
+	ResourceMember        ResourceType = "member"
+	ResourceChecklist     ResourceType = "checklist"
+	ResourceChecklistItem ResourceType = "checklist_item"
+	ResourceAttachment    ResourceType = "attachment"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionMove    Action = "move"
+	ActionAttach  Action = "attach"
+	ActionDetach  Action = "detach"
+	ActionRestore Action = "restore"
+	ActionImport  Action = "import"
+)
+
+// EventType is name of activity event used in webhook subscriptions, e.g. task.move
+func EventType(resourceType ResourceType, action Action) string {
+	return string(resourceType) + "." + string(action)
+}
+
+var resourceTypes = []ResourceType{ResourceProject, ResourceColumn, ResourceTask, ResourceComment, ResourceLabel,
+	ResourceMember, ResourceChecklist, ResourceChecklistItem, ResourceAttachment}
+
+var actions = []Action{ActionCreate, ActionUpdate, ActionDelete, ActionMove, ActionAttach, ActionDetach,
+	ActionRestore, ActionImport}
+
+// IsEventType reports whether event has form of EventType result with known resource type and action
+func IsEventType(event string) bool {
+	for _, resourceType := range resourceTypes {
+		for _, action := range actions {
+			if event == EventType(resourceType, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type Activity struct {
+	Id Id `json:"id"`
+	// Seq grows in commit order unlike Id, it's used as id of streamed events
+	Seq       Id  `json:"-"`
+	ProjectId Id  `json:"project_id"`
+	TaskId    *Id `json:"task_id" swaggertype:"primitive,integer"`
+	// 0 if actor was deleted
+	ActorId      Id           `json:"actor_id"`
+	CreatedAt    time.Time    `json:"created_at"`
+	ResourceType ResourceType `json:"resource_type" swaggertype:"string" enums:"project,column,task,comment,label,member,checklist,checklist_item,attachment"`
+	ResourceId   Id           `json:"resource_id"`
+	Action       Action       `json:"action" swaggertype:"string" enums:"create,update,delete,move,attach,detach,restore,import"`
+	Diff         ActivityDiff `json:"diff"`
+}
+
+// ActivityDiff contains state of resource before and after change,
+// before is null for created resources and after is null for deleted ones
+type ActivityDiff struct {
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	After  json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+type ActivityPage struct {
+	Activity []Activity `json:"activity"`
+	// empty if there is no more activity
+	NextCursor string `json:"next_cursor"`
+}
+
+type SearchHit struct {
+	Type        string `json:"type" enums:"task,comment"`
+	ProjectId   Id     `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	ColumnId    Id     `json:"column_id"`
+	ColumnName  string `json:"column_name"`
+	TaskId      Id     `json:"task_id"`
+	// present only for comment hits
+	CommentId *Id     `json:"comment_id,omitempty" swaggertype:"primitive,integer"`
+	Rank      float32 `json:"rank"`
+	// HTML-escaped matched text fragments with search terms wrapped in <b></b>
+	Snippet string `json:"snippet"`
+}
+
+// ArchiveVersion is incremented on incompatible changes of project archive format
+const ArchiveVersion = 1
+
+// ProjectArchive is portable representation of project, ids are meaningful only within archive
+type ProjectArchive struct {
+	ProjectArchiveHeader
+	// columns in board order, with tasks in column order
+	Columns []ArchivedColumn `json:"columns" validate:"min=1,max=1000,dive"`
+}
+
+type ProjectArchiveHeader struct {
+	Version    int             `json:"version" validate:"eq=1" example:"1"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Project    ArchivedProject `json:"project"`
+	Labels     []Label         `json:"labels" validate:"max=1000,dive"`
+}
+
+type ArchivedProject struct {
+	Id Id `json:"id"`
+	ProjectSettableFields
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ArchivedColumn struct {
+	Id Id `json:"id"`
+	ColumnSettableFields
+	Tasks []ArchivedTask `json:"tasks" validate:"max=10000,dive"`
+}
+
+type ArchivedTask struct {
+	Id Id `json:"id"`
+	TaskSettableFields
+	LabelIds []Id              `json:"label_ids"`
+	Comments []ArchivedComment `json:"comments" validate:"max=10000,dive"`
+}
+
+type ArchivedComment struct {
+	Id Id `json:"id"`
+	CommentSettableFields
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ProjectImport maps ids from archive to ids of created resources
+type ProjectImport struct {
+	ProjectId Id        `json:"project_id"`
+	Labels    map[Id]Id `json:"labels"`
+	Columns   map[Id]Id `json:"columns"`
+	Tasks     map[Id]Id `json:"tasks"`
+	Comments  map[Id]Id `json:"comments"`
+}
+
+// TaskReportRow is task summary used in reports
+type TaskReportRow struct {
+	Id         Id
+	ColumnName string
+	// 1-based position of task within column
+	Position     int
+	Name         string
+	Description  string
+	CommentCount int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type TrashItem struct {
+	Type      string `json:"type" enums:"project,column,task,comment"`
+	Id        Id     `json:"id"`
+	ProjectId Id     `json:"project_id"`
+	// present only for comments
+	TaskId *Id `json:"task_id,omitempty" swaggertype:"primitive,integer"`
+	// name of project, column or task, or beginning of comment text
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (c *Caller) SetCaller(userId Id) {
+	c.UserId = userId
+}
+
+func (p *Precondition) SetPrecondition(ifMatch Version) {
+	p.IfMatch = ifMatch
+}
+
+// Check returns error if version of resource doesn't match If-Match header
+func (p Precondition) Check(version Version) error {
+	if p.IfMatch != 0 && p.IfMatch != version {
+		return NewPreconditionFailedError()
+	}
+	return nil
+}
+
+// NewWriteError is NewNotFoundOrInternalError for conditional update or delete, if no rows were affected
+// while version was required, resource was modified concurrently after Check
+func (p Precondition) NewWriteError(description string, err error) error {
+	if p.IfMatch != 0 && dbCommon.IsNoRowsError(err) {
+		return NewPreconditionFailedError()
+	}
+	return NewNotFoundOrInternalError(description, err)
+}
+
+// Includes reports whether role grants all permissions of other role
+func (r Role) Includes(other Role) bool {
+	return roleLevels[r] >= roleLevels[other]
+}
+
+func (resource User) GetId() Id {
+	return resource.Id
+}
+
 func (resource Project) GetId() Id {
 	return resource.Id
 }
@@ -84,10 +467,34 @@ func (resource TaskExpanded) GetId() Id {
 	return resource.Id
 }
 
+func (resource Label) GetId() Id {
+	return resource.Id
+}
+
 func (resource Comment) GetId() Id {
 	return resource.Id
 }
 
+func (resource Checklist) GetId() Id {
+	return resource.Id
+}
+
+func (resource ChecklistItem) GetId() Id {
+	return resource.Id
+}
+
+func (resource Attachment) GetId() Id {
+	return resource.Id
+}
+
+func (resource Webhook) GetId() Id {
+	return resource.Id
+}
+
+func (resource ProjectImport) GetId() Id {
+	return resource.ProjectId
+}
+
 func CalculateRankHigher(rank Rank) Rank {
 	return CalculateRankBetween(rank, "{{{{{{{{{{{{{{{{")
 }
@@ -96,6 +503,13 @@ func CalculateRankInitial() Rank {
 	return CalculateRankBetween("", "")
 }
 
+// rankLength grows when items are repeatedly inserted at the same place, long ranks mean that rebalance is needed
+var rankLength = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "gorello_rank_length",
+	Help:    "Length of ranks calculated between two ranks.",
+	Buckets: prometheus.ExponentialBuckets(1, 2, 8),
+})
+
 // naive implementation of lexicographic ranking algorithm
 func CalculateRankBetween(rankA Rank, rankB Rank) Rank {
 	var smaller, bigger Rank
@@ -119,7 +533,64 @@ func CalculateRankBetween(rankA Rank, rankB Rank) Rank {
 		}
 		if upperBound > res[i]+1 {
 			res[i] += (upperBound - res[i]) / 2
+			rankLength.Observe(float64(len(res)))
 			return Rank(res)
 		}
 	}
 }
+
+// CalculateRanksEvenly returns n ascending ranks of the same minimal length, which are evenly spread,
+// so there is room for insertion between any two of them and around them
+func CalculateRanksEvenly(n int) []Rank {
+	const base = int('z' - 'b' + 1) // 'a' is never used, so rank doesn't end with it
+	length, capacity := 1, base
+	for capacity <= 2*n {
+		length++
+		capacity *= base
+	}
+	ranks := make([]Rank, n)
+	for i := range ranks {
+		value := (i + 1) * capacity / (n + 1)
+		res := make([]byte, length)
+		for j := length - 1; j >= 0; j-- {
+			res[j] = byte('b' + value%base)
+			value /= base
+		}
+		ranks[i] = Rank(res)
+	}
+	return ranks
+}
+
+func (resource Project) GetVersion() Version {
+	return resource.Version
+}
+
+// expanded project includes sub-resources, so its version doesn't cover the whole representation
+func (resource ProjectExpanded) GetVersion() Version {
+	return 0
+}
+
+func (resource Column) GetVersion() Version {
+	return resource.Version
+}
+
+func (resource Task) GetVersion() Version {
+	return resource.Version
+}
+
+// see ProjectExpanded.GetVersion
+func (resource TaskExpanded) GetVersion() Version {
+	return 0
+}
+
+func (resource Checklist) GetVersion() Version {
+	return resource.Version
+}
+
+func (resource ChecklistItem) GetVersion() Version {
+	return resource.Version
+}
+
+func (resource Comment) GetVersion() Version {
+	return resource.Version
+}
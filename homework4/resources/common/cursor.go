@@ -0,0 +1,29 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Cursor points to the last item of the previously returned page.
+// Clients receive it encoded and should treat it as opaque string.
+type Cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	Id   Id     `json:"i"`
+}
+
+func EncodeCursor(c Cursor) string {
+	bin, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bin)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	c := Cursor{}
+	bin, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(bin, &c)
+	return c, err
+}
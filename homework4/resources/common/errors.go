@@ -10,6 +10,12 @@ type ErrorType int
 const (
 	NotFound ErrorType = iota
 	Conflict
+	BadRequest
+	Unauthorized
+	Forbidden
+	PreconditionFailed
+	Unprocessable
+	PayloadTooLarge
 	InternalError
 )
 
@@ -39,6 +45,30 @@ func NewConflictError(description string) error {
 	return Error{Type: Conflict, Description: description}
 }
 
+func NewBadRequestError(description string) error {
+	return Error{Type: BadRequest, Description: description}
+}
+
+func NewUnauthorizedError() error {
+	return Error{Type: Unauthorized, Description: "unauthorized"}
+}
+
+func NewForbiddenError() error {
+	return Error{Type: Forbidden, Description: "forbidden"}
+}
+
+func NewPreconditionFailedError() error {
+	return Error{Type: PreconditionFailed, Description: "resource version doesn't match If-Match header"}
+}
+
+func NewUnprocessableError(description string) error {
+	return Error{Type: Unprocessable, Description: description}
+}
+
+func NewPayloadTooLargeError(description string) error {
+	return Error{Type: PayloadTooLarge, Description: description}
+}
+
 func NewInternalError(description string, cause error) error {
 	return Error{Type: InternalError, Description: description, Cause: cause}
 }
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MergePatch is embedded into requests which accept RFC 7396 merge patch of resource settable fields
+type MergePatch struct {
+	Patch map[string]json.RawMessage `json:"-"`
+}
+
+func (p *MergePatch) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &p.Patch)
+}
+
+// Apply decodes supplied fields into settable fields pointed by dst, which should contain current state,
+// and validates only them. Null is accepted only for nullable fields and resets them.
+// It returns values of supplied fields keyed by their JSON names.
+func (p MergePatch) Apply(dst interface{}) (map[string]interface{}, error) {
+	v := reflect.ValueOf(dst).Elem()
+	fieldsByJSONName := map[string]int{}
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		fieldsByJSONName[name] = i
+	}
+	values := map[string]interface{}{}
+	supplied := make([]string, 0, len(p.Patch))
+	for name, value := range p.Patch {
+		i, ok := fieldsByJSONName[name]
+		if !ok {
+			return nil, NewBadRequestError("unknown field " + name)
+		}
+		field := v.Field(i)
+		if string(value) == "null" {
+			if field.Kind() != reflect.Ptr {
+				return nil, NewBadRequestError("field " + name + " cannot be null")
+			}
+			field.Set(reflect.Zero(field.Type()))
+		} else {
+			// pointer fields are decoded into new values, so current state isn't modified by reference
+			decoded := reflect.New(field.Type())
+			if err := json.Unmarshal(value, decoded.Interface()); err != nil {
+				return nil, NewBadRequestError("invalid value of field " + name)
+			}
+			field.Set(decoded.Elem())
+		}
+		values[name] = field.Interface()
+		supplied = append(supplied, v.Type().Field(i).Name)
+	}
+	if err := validate.StructPartial(dst, supplied...); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return nil, NewUnprocessableError(validationErrors.Error())
+		}
+		return nil, NewInternalError("cannot validate patch", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,17 @@
+package common
+
+import "github.com/go-playground/validator/v10"
+
+var validate = validator.New()
+
+// Validate validates struct which is built by request handler instead of being decoded from request body,
+// failed validation results in Unprocessable error
+func Validate(s interface{}) error {
+	if err := validate.Struct(s); err != nil {
+		if validationErrors, ok := err.(validator.ValidationErrors); ok {
+			return NewUnprocessableError(validationErrors.Error())
+		}
+		return NewInternalError("cannot validate struct", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"go.uber.org/zap"
+)
+
+// channel is notified by trigger on every activity insert, notifications are delivered only after commit
+const channel = "activity"
+
+const reconnectDelay = 5 * time.Second
+
+// bufferSize is number of events which can be queued for single subscriber,
+// subscriber which falls behind is dropped
+const bufferSize = 64
+
+// maxMissed is max number of events replayed to reconnected subscriber
+const maxMissed = 500
+
+type SubscribeRequest struct {
+	common.Caller
+	ProjectId common.Id
+	// id of the last event received by client before reconnect, 0 for new clients,
+	// event ids are activity seqs, so they follow commit order
+	LastEventId common.Id
+}
+
+type Subscription struct {
+	// Missed contains events committed since LastEventId in commit order
+	Missed []common.Activity
+	// Reset is true if there are more missed events than could be replayed,
+	// so client should reload the whole board
+	Reset bool
+	// Events is closed when subscriber doesn't keep up with events
+	Events    <-chan common.Activity
+	events    chan common.Activity
+	projectId common.Id
+}
+
+type notification struct {
+	Id        common.Id `json:"id"`
+	ProjectId common.Id `json:"project_id"`
+}
+
+var hub = struct {
+	sync.Mutex
+	subscribers map[common.Id]map[chan common.Activity]struct{}
+}{subscribers: map[common.Id]map[chan common.Activity]struct{}{}}
+
+// Run listens for activity notifications until ctx is done, it should be called after db initialization
+func Run(ctx context.Context) {
+	for {
+		err := db.Listen(ctx, channel, dispatch)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Zap.Error("activity notifications listening failed", zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func dispatch(payload string) {
+	n := notification{}
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		logger.Zap.Error("invalid activity notification", zap.String("payload", payload), zap.Error(err))
+		return
+	}
+	if !hasSubscribers(n.ProjectId) {
+		return
+	}
+	a, err := db.Query().Activity().Get(context.Background(), n.Id)
+	if err != nil {
+		logger.Zap.Error("cannot get notified activity", zap.Error(err))
+		return
+	}
+	broadcast(a)
+}
+
+func hasSubscribers(projectId common.Id) bool {
+	hub.Lock()
+	defer hub.Unlock()
+	return len(hub.subscribers[projectId]) > 0
+}
+
+func broadcast(a common.Activity) {
+	hub.Lock()
+	defer hub.Unlock()
+	for events := range hub.subscribers[a.ProjectId] {
+		select {
+		case events <- a:
+		default:
+			delete(hub.subscribers[a.ProjectId], events)
+			close(events)
+		}
+	}
+}
+
+func (r SubscribeRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+		return nil, err
+	}
+	s := &Subscription{projectId: r.ProjectId, events: make(chan common.Activity, bufferSize)}
+	s.Events = s.events
+	hub.Lock()
+	if hub.subscribers[r.ProjectId] == nil {
+		hub.subscribers[r.ProjectId] = map[chan common.Activity]struct{}{}
+	}
+	hub.subscribers[r.ProjectId][s.events] = struct{}{}
+	hub.Unlock()
+	if r.LastEventId == 0 {
+		return s, nil
+	}
+	// subscription is made before reading missed events, so nothing is lost in between,
+	// events received from both sources should be deduplicated by seq
+	missed, err := db.Query().Activity().GetByProjectAfterSeq(ctx, r.ProjectId, r.LastEventId, maxMissed+1)
+	if err != nil {
+		s.Close()
+		return nil, common.NewInternalError("cannot get missed activity", err)
+	}
+	if len(missed) > maxMissed {
+		s.Reset = true
+	} else {
+		s.Missed = missed
+	}
+	return s, nil
+}
+
+// Close must be called when client disconnects
+func (s *Subscription) Close() {
+	hub.Lock()
+	defer hub.Unlock()
+	if _, ok := hub.subscribers[s.projectId][s.events]; !ok {
+		// already dropped by broadcast
+		return
+	}
+	delete(hub.subscribers[s.projectId], s.events)
+	if len(hub.subscribers[s.projectId]) == 0 {
+		delete(hub.subscribers, s.projectId)
+	}
+	close(s.events)
+}
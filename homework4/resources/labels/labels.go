@@ -0,0 +1,268 @@
+package labels
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type CreateRequest struct {
+	rcommon.Caller
+	ProjectId rcommon.Id
+	rcommon.LabelSettableFields
+}
+
+type ReadRequest struct {
+	rcommon.Caller
+	ProjectId rcommon.Id
+	LabelId   rcommon.Id
+}
+
+type ReadCollectionRequest struct {
+	rcommon.Caller
+	ProjectId rcommon.Id
+}
+
+type UpdateRequest struct {
+	rcommon.Caller
+	ProjectId rcommon.Id
+	LabelId   rcommon.Id
+	rcommon.LabelSettableFields
+}
+
+type DeleteRequest struct {
+	rcommon.Caller
+	ProjectId rcommon.Id
+	LabelId   rcommon.Id
+}
+
+type ReadTaskLabelsRequest struct {
+	rcommon.Caller
+	TaskId rcommon.Id
+}
+
+type AttachRequest struct {
+	rcommon.Caller
+	TaskId  rcommon.Id
+	LabelId rcommon.Id
+}
+
+type DetachRequest struct {
+	rcommon.Caller
+	TaskId  rcommon.Id
+	LabelId rcommon.Id
+}
+
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return rcommon.Label{}, err
+	}
+	if err := checkNameIsFree(ctx, r.ProjectId, 0, r.Name); err != nil {
+		return rcommon.Label{}, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return rcommon.Label{}, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	label, err := db.QueryWithTX(tx).Labels().Create(ctx, r.ProjectId, r.LabelSettableFields)
+	if err != nil {
+		return rcommon.Label{}, rcommon.NewInternalError("cannot create label", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceLabel,
+		ResourceId:   label.Id,
+		Action:       rcommon.ActionCreate,
+		After:        label,
+	})
+	if err != nil {
+		return rcommon.Label{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return rcommon.Label{}, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return label, nil
+}
+
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleViewer); err != nil {
+		return rcommon.Label{}, err
+	}
+	label, err := db.Query().Labels().Get(ctx, r.ProjectId, r.LabelId)
+	return label, rcommon.MaybeNewNotFoundOrInternalError("cannot get label", err)
+}
+
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleViewer); err != nil {
+		return []rcommon.Label{}, err
+	}
+	labels, err := db.Query().Labels().GetMultiple(ctx, r.ProjectId)
+	return labels, rcommon.MaybeNewInternalError("cannot get labels", err)
+}
+
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	if err := checkNameIsFree(ctx, r.ProjectId, r.LabelId, r.Name); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Labels().Get(ctx, r.ProjectId, r.LabelId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get label", err)
+	}
+	if err := db.QueryWithTX(tx).Tasks().IncrementVersionByLabel(ctx, r.LabelId); err != nil {
+		return nil, rcommon.NewInternalError("cannot increment versions of labeled tasks", err)
+	}
+	if err := db.QueryWithTX(tx).Labels().Update(ctx, r.ProjectId, r.LabelId, r.LabelSettableFields); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot update label", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceLabel,
+		ResourceId:   r.LabelId,
+		Action:       rcommon.ActionUpdate,
+		Before:       before,
+		After:        rcommon.Label{Id: r.LabelId, LabelSettableFields: r.LabelSettableFields},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Labels().Get(ctx, r.ProjectId, r.LabelId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get label", err)
+	}
+	if err := db.QueryWithTX(tx).Tasks().IncrementVersionByLabel(ctx, r.LabelId); err != nil {
+		return nil, rcommon.NewInternalError("cannot increment versions of labeled tasks", err)
+	}
+	if err := db.QueryWithTX(tx).Labels().Delete(ctx, r.ProjectId, r.LabelId); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot delete label", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: rcommon.ResourceLabel,
+		ResourceId:   r.LabelId,
+		Action:       rcommon.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r ReadTaskLabelsRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, rcommon.RoleViewer); err != nil {
+		return []rcommon.Label{}, err
+	}
+	labels, err := db.Query().Labels().GetByTask(ctx, r.TaskId)
+	return labels, rcommon.MaybeNewInternalError("cannot get task labels", err)
+}
+
+func (r AttachRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, rcommon.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	label, err := db.Query().Labels().Get(ctx, task.ProjectId, r.LabelId)
+	if common.IsNoRowsError(err) {
+		return nil, rcommon.NewConflictError("label not found in task project")
+	} else if err != nil {
+		return nil, rcommon.NewInternalError("cannot get label", err)
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := db.QueryWithTX(tx).Labels().Attach(ctx, r.TaskId, r.LabelId); err != nil {
+		return nil, rcommon.NewInternalError("cannot attach label", err)
+	}
+	if err := db.QueryWithTX(tx).Tasks().IncrementVersion(ctx, r.TaskId); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot increment task version", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: rcommon.ResourceLabel,
+		ResourceId:   r.LabelId,
+		Action:       rcommon.ActionAttach,
+		After:        label,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r DetachRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, rcommon.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := db.QueryWithTX(tx).Labels().Detach(ctx, r.TaskId, r.LabelId); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot detach label", err)
+	}
+	if err := db.QueryWithTX(tx).Tasks().IncrementVersion(ctx, r.TaskId); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot increment task version", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: rcommon.ResourceLabel,
+		ResourceId:   r.LabelId,
+		Action:       rcommon.ActionDetach,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func checkNameIsFree(ctx context.Context, projectId, labelId rcommon.Id, name string) error {
+	label, err := db.Query().Labels().GetByName(ctx, projectId, name)
+	if err == nil && label.Id != labelId {
+		return rcommon.NewConflictError("label with same name exists in project")
+	} else if err != nil && !common.IsNoRowsError(err) {
+		return rcommon.NewInternalError("cannot get label by name", err)
+	}
+	return nil
+}
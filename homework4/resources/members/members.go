@@ -0,0 +1,128 @@
+package members
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type ReadCollectionRequest struct {
+	common.Caller
+	ProjectId common.Id
+}
+
+type UpdateRequest struct {
+	common.Caller
+	ProjectId common.Id
+	MemberId  common.Id
+	common.MemberSettableFields
+}
+
+type DeleteRequest struct {
+	common.Caller
+	ProjectId common.Id
+	MemberId  common.Id
+}
+
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+		return []common.Member{}, err
+	}
+	members, err := db.Query().Members().GetMultiple(ctx, r.ProjectId)
+	return members, common.MaybeNewInternalError("cannot get members", err)
+}
+
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return nil, err
+	}
+	user, err := db.Query().Users().Get(ctx, r.MemberId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get user", err)
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if r.Role != common.RoleOwner {
+		if err := checkNotLastOwner(ctx, tx, r.ProjectId, r.MemberId); err != nil {
+			return nil, err
+		}
+	}
+	role, err := db.QueryWithTX(tx).Members().GetRole(ctx, r.ProjectId, r.MemberId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get member role", err)
+	}
+	if err := db.QueryWithTX(tx).Members().Set(ctx, r.ProjectId, r.MemberId, r.Role); err != nil {
+		return nil, common.NewInternalError("cannot set member role", err)
+	}
+	change := activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: common.ResourceMember,
+		ResourceId:   r.MemberId,
+		Action:       common.ActionUpdate,
+		After:        common.Member{User: user, MemberSettableFields: r.MemberSettableFields},
+	}
+	if role == "" {
+		change.Action = common.ActionCreate
+	} else {
+		change.Before = common.Member{User: user, MemberSettableFields: common.MemberSettableFields{Role: role}}
+	}
+	if err := activity.Record(ctx, tx, r.UserId, change); err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := checkNotLastOwner(ctx, tx, r.ProjectId, r.MemberId); err != nil {
+		return nil, err
+	}
+	role, err := db.QueryWithTX(tx).Members().GetRole(ctx, r.ProjectId, r.MemberId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get member role", err)
+	}
+	if err := db.QueryWithTX(tx).Members().Delete(ctx, r.ProjectId, r.MemberId); err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot delete member", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: common.ResourceMember,
+		ResourceId:   r.MemberId,
+		Action:       common.ActionDelete,
+		Before:       common.Member{MemberSettableFields: common.MemberSettableFields{Role: role}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// project must always have at least one owner, otherwise nobody can manage it
+func checkNotLastOwner(ctx context.Context, tx db.TX, projectId, userId common.Id) error {
+	ownersIds, err := db.QueryWithTX(tx).Members().GetAndBlockOwnersIds(ctx, projectId)
+	if err != nil {
+		return common.NewInternalError("cannot get project owners", err)
+	}
+	if len(ownersIds) == 1 && ownersIds[0] == userId {
+		return common.NewConflictError("project must have at least one owner")
+	}
+	return nil
+}
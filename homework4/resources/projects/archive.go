@@ -0,0 +1,219 @@
+package projects
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type ExportRequest struct {
+	common.Caller
+	ProjectId common.Id
+}
+
+// Export writes archive of project, which is accessible by caller of ExportRequest,
+// all data is read from the same db snapshot
+type Export struct {
+	projectId common.Id
+	tx        db.TX
+	header    common.ProjectArchiveHeader
+	columns   []common.ArchivedColumn
+}
+
+type ImportRequest struct {
+	common.Caller
+	common.ProjectArchive
+}
+
+// Handle reads project with labels and list of columns, so errors are reported before response is started,
+// tasks are read column by column by Export.Stream, so archive is streamed without loading the whole project
+// into memory
+func (r ExportRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginSnapshot(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	e := &Export{projectId: r.ProjectId, tx: tx}
+	if err := e.readHeader(ctx); err != nil {
+		db.EndSnapshot(ctx, tx)
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Export) readHeader(ctx context.Context) (err error) {
+	q := db.QueryWithTX(e.tx)
+	e.header = common.ProjectArchiveHeader{Version: common.ArchiveVersion, ExportedAt: time.Now().UTC()}
+	if e.header.Project, err = q.Archive().GetProject(ctx, e.projectId); err != nil {
+		return common.NewNotFoundOrInternalError("cannot get project", err)
+	}
+	if e.header.Labels, err = q.Labels().GetMultiple(ctx, e.projectId); err != nil {
+		return common.NewInternalError("cannot get labels", err)
+	}
+	if e.columns, err = q.Archive().GetColumns(ctx, e.projectId); err != nil {
+		return common.NewInternalError("cannot get columns", err)
+	}
+	return nil
+}
+
+func (e *Export) ContentType() string {
+	return "application/json;charset=utf-8"
+}
+
+func (e *Export) Filename() string {
+	return fmt.Sprintf("project-%d.json", e.projectId)
+}
+
+// Stream writes archive column by column and releases snapshot
+func (e *Export) Stream(ctx context.Context, w io.Writer) error {
+	defer db.EndSnapshot(ctx, e.tx)
+	data, err := json.Marshal(e.header)
+	if err != nil {
+		return common.NewInternalError("cannot marshal archive header", err)
+	}
+	// header object is left open to append columns array to it
+	if _, err := w.Write(append(data[:len(data)-1], `,"columns":[`...)); err != nil {
+		return err
+	}
+	for i, column := range e.columns {
+		if column.Tasks, err = db.QueryWithTX(e.tx).Archive().GetTasks(ctx, column.Id); err != nil {
+			return common.NewInternalError("cannot get tasks", err)
+		}
+		if data, err = json.Marshal(column); err != nil {
+			return common.NewInternalError("cannot marshal column", err)
+		}
+		if i > 0 {
+			data = append([]byte{','}, data...)
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, "]}")
+	return err
+}
+
+func (r ImportRequest) Handle(ctx context.Context) (interface{}, error) {
+	return importArchive(ctx, r.UserId, r.ProjectArchive)
+}
+
+// importArchive creates project from archive with user as owner, assignees aren't imported,
+// since users differ between instances. Tasks and columns get new ranks in archive order.
+func importArchive(ctx context.Context, userId common.Id, archive common.ProjectArchive) (common.ProjectImport, error) {
+	result := common.ProjectImport{
+		Labels:   map[common.Id]common.Id{},
+		Columns:  map[common.Id]common.Id{},
+		Tasks:    map[common.Id]common.Id{},
+		Comments: map[common.Id]common.Id{},
+	}
+	if err := validateArchive(archive); err != nil {
+		return result, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return result, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	q := db.QueryWithTX(tx)
+	project, err := q.Projects().Create(ctx, archive.Project.ProjectSettableFields)
+	if err != nil {
+		return result, common.NewInternalError("cannot create project", err)
+	}
+	result.ProjectId = project.Id
+	if err := q.Members().Set(ctx, project.Id, userId, common.RoleOwner); err != nil {
+		return result, common.NewInternalError("cannot add project owner", err)
+	}
+	for _, l := range archive.Labels {
+		label, err := q.Labels().Create(ctx, project.Id, l.LabelSettableFields)
+		if err != nil {
+			return result, common.NewInternalError("cannot create label", err)
+		}
+		result.Labels[l.Id] = label.Id
+	}
+	columnsRanks := common.CalculateRanksEvenly(len(archive.Columns))
+	for i, c := range archive.Columns {
+		column, err := q.Columns().Create(ctx, project.Id, c.ColumnSettableFields, columnsRanks[i])
+		if err != nil {
+			return result, common.NewInternalError("cannot create column", err)
+		}
+		result.Columns[c.Id] = column.Id
+		tasksRanks := common.CalculateRanksEvenly(len(c.Tasks))
+		for j, t := range c.Tasks {
+			t.AssigneeId = nil
+			task, err := q.Tasks().Create(ctx, project.Id, column.Id, t.TaskSettableFields, tasksRanks[j])
+			if err != nil {
+				return result, common.NewInternalError("cannot create task", err)
+			}
+			result.Tasks[t.Id] = task.Id
+			for _, labelId := range t.LabelIds {
+				if err := q.Labels().Attach(ctx, task.Id, result.Labels[labelId]); err != nil {
+					return result, common.NewInternalError("cannot attach label", err)
+				}
+			}
+			for _, comment := range t.Comments {
+				id, err := q.Archive().CreateComment(ctx, task.Id, comment.Text, comment.CreatedAt)
+				if err != nil {
+					return result, common.NewInternalError("cannot create comment", err)
+				}
+				result.Comments[comment.Id] = id
+			}
+		}
+	}
+	err = activity.Record(ctx, tx, userId, activity.Change{
+		ProjectId:    project.Id,
+		ResourceType: common.ResourceProject,
+		ResourceId:   project.Id,
+		Action:       common.ActionImport,
+		After:        project,
+	})
+	if err != nil {
+		return result, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return result, common.NewInternalError("cannot commit transaction", err)
+	}
+	return result, nil
+}
+
+// validateArchive checks constraints which cannot be expressed with validation tags,
+// WIP limits are checked here, since imported columns are filled in the same transaction they are created in
+func validateArchive(a common.ProjectArchive) error {
+	labelsIds := map[common.Id]bool{}
+	labelsNames := map[string]bool{}
+	for _, l := range a.Labels {
+		if labelsIds[l.Id] || labelsNames[l.Name] {
+			return common.NewUnprocessableError("labels ids and names must be unique")
+		}
+		labelsIds[l.Id], labelsNames[l.Name] = true, true
+	}
+	columnsNames := map[string]bool{}
+	for _, c := range a.Columns {
+		if columnsNames[c.Name] {
+			return common.NewUnprocessableError("columns names must be unique")
+		}
+		columnsNames[c.Name] = true
+		if c.WipLimit != nil && !a.Project.WipLimitsAdvisory && len(c.Tasks) > *c.WipLimit {
+			return common.NewUnprocessableError("columns tasks must not exceed WIP limits unless they are advisory")
+		}
+		for _, t := range c.Tasks {
+			attached := map[common.Id]bool{}
+			for _, labelId := range t.LabelIds {
+				if !labelsIds[labelId] || attached[labelId] {
+					return common.NewUnprocessableError("task labels must be unique and present in archive")
+				}
+				attached[labelId] = true
+			}
+		}
+	}
+	return nil
+}
@@ -1,76 +1,314 @@
 package projects
 
 import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	dbProjects "github.com/AndreyKlimchuk/golang-learning/homework4/db/projects"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
 )
 
 type CreateRequest struct {
+	common.Caller
 	common.ProjectSettableFields
 }
 
 type ReadRequest struct {
+	common.Caller
 	ProjectId common.Id
 	Expanded  bool
 }
 
 type ReadCollectionRequest struct {
+	common.Caller
+	Limit  int `validate:"min=1,max=100"`
+	Cursor string
+	Sort   string `validate:"oneof=name id created"`
+	// filter projects by name substring, case insensitive
+	Name string `validate:"max=500"`
 }
 
 type UpdateRequest struct {
+	common.Caller
+	common.Precondition
 	ProjectId common.Id
 	common.ProjectSettableFields
 }
 
+type PatchRequest struct {
+	common.Caller
+	common.Precondition
+	common.MergePatch
+	ProjectId common.Id
+}
+
 type DeleteRequest struct {
+	common.Caller
+	common.Precondition
 	ProjectId common.Id
 }
 
-func (r CreateRequest) Handle() (interface{}, error) {
-	tx, err := db.Begin()
+type RestoreRequest struct {
+	common.Caller
+	ProjectId common.Id
+}
+
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return common.Project{}, common.NewInternalError("cannot begin transaction", err)
 	}
-	defer db.Rollback(tx)
-	project, err := db.QueryWithTX(tx).Projects().Create(r.Name, r.Description)
+	defer db.Rollback(ctx, tx)
+	project, err := db.QueryWithTX(tx).Projects().Create(ctx, r.ProjectSettableFields)
 	if err != nil {
 		return common.Project{}, common.NewInternalError("cannot create project", err)
 	}
+	if err := db.QueryWithTX(tx).Members().Set(ctx, project.Id, r.UserId, common.RoleOwner); err != nil {
+		return common.Project{}, common.NewInternalError("cannot add project owner", err)
+	}
 	rank := common.CalculateRankInitial()
-	column, err := db.QueryWithTX(tx).Columns().Create(project.Id, common.DefaultColumnName, rank)
+	column, err := db.QueryWithTX(tx).Columns().Create(ctx, project.Id, common.ColumnSettableFields{Name: common.DefaultColumnName}, rank)
 	if err != nil {
 		return common.Project{}, common.NewInternalError("cannot create column", err)
 	}
-	if err := db.Commit(tx); err != nil {
+	projectExpanded := common.ProjectExpanded{
+		Project: project,
+		Columns: []common.ColumnExpanded{column},
+		Labels:  []common.Label{},
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    project.Id,
+		ResourceType: common.ResourceProject,
+		ResourceId:   project.Id,
+		Action:       common.ActionCreate,
+		After:        projectExpanded,
+	})
+	if err != nil {
+		return common.Project{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
 		return common.Project{}, common.NewInternalError("cannot commit transaction", err)
 	}
-	projectExpanded := common.ProjectExpanded{Project: project, Columns: []common.ColumnExpanded{column}}
 	return projectExpanded, nil
 }
 
-func (r ReadRequest) Handle() (interface{}, error) {
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+		return common.Project{}, err
+	}
 	var project resources.Resource
 	var err error
 	if r.Expanded {
-		project, err = db.Query().Projects().GetExpanded(r.ProjectId)
+		project, err = db.Query().Projects().GetExpanded(ctx, r.ProjectId)
 	} else {
-		project, err = db.Query().Projects().Get(r.ProjectId)
+		project, err = db.Query().Projects().Get(ctx, r.ProjectId)
 	}
 	return project, common.MaybeNewNotFoundOrInternalError("cannot get project", err)
 }
 
-func (_ ReadCollectionRequest) Handle() (interface{}, error) {
-	project, err := db.Query().Projects().GetMultiple()
-	return project, common.MaybeNewInternalError("cannot get projects", err)
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	params := dbProjects.GetMultipleParams{
+		MemberId:     r.UserId,
+		Limit:        r.Limit,
+		SortKey:      dbProjects.SortKeys[r.Sort],
+		NameContains: r.Name,
+	}
+	if r.Cursor != "" {
+		cursor, err := common.DecodeCursor(r.Cursor)
+		if err != nil || cursor.Sort != r.Sort || !validCursorKey(cursor) {
+			return common.ProjectsPage{}, common.NewBadRequestError("invalid cursor")
+		}
+		params.After = dbProjects.Keyset{Key: cursor.Key, Id: cursor.Id}
+	}
+	projects, next, err := db.Query().Projects().GetMultiple(ctx, params)
+	if err != nil {
+		return common.ProjectsPage{}, common.NewInternalError("cannot get projects", err)
+	}
+	page := common.ProjectsPage{Projects: projects}
+	if next != nil {
+		page.NextCursor = common.EncodeCursor(common.Cursor{Sort: r.Sort, Key: next.Key, Id: next.Id})
+	}
+	return page, nil
+}
+
+// timestamptzLayouts match text representation of timestamptz in ISO date style with any time zone offset
+var timestamptzLayouts = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999-07:00:00",
+}
+
+// validCursorKey checks that cursor key can be cast to type of its sort key,
+// so malformed cursor is rejected instead of failing the query
+func validCursorKey(cursor common.Cursor) bool {
+	switch cursor.Sort {
+	case "id":
+		_, err := strconv.ParseInt(cursor.Key, 10, 32)
+		return err == nil
+	case "created":
+		for _, layout := range timestamptzLayouts {
+			if _, err := time.Parse(layout, cursor.Key); err == nil {
+				return true
+			}
+		}
+		return false
+	default:
+		return utf8.ValidString(cursor.Key) && !strings.ContainsRune(cursor.Key, 0)
+	}
+}
+
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Projects().Get(ctx, r.ProjectId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get project", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Projects().Update(ctx, r.ProjectId, r.ProjectSettableFields, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot update project", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: common.ResourceProject,
+		ResourceId:   r.ProjectId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        common.Project{Id: r.ProjectId, ProjectSettableFields: r.ProjectSettableFields},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r PatchRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Projects().Get(ctx, r.ProjectId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get project", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	after := before
+	values, err := r.Apply(&after.ProjectSettableFields)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Projects().Patch(ctx, r.ProjectId, values, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot patch project", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: common.ResourceProject,
+		ResourceId:   r.ProjectId,
+		Action:       common.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
 }
 
-func (r UpdateRequest) Handle() (interface{}, error) {
-	err := db.Query().Projects().Update(r.ProjectId, r.Name, r.Description)
-	return nil, common.MaybeNewNotFoundOrInternalError("cannot update project", err)
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Projects().Get(ctx, r.ProjectId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get project", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Projects().Delete(ctx, r.ProjectId, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot delete project", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: common.ResourceProject,
+		ResourceId:   r.ProjectId,
+		Action:       common.ActionDelete,
+		Before:       before,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
 }
 
-func (r DeleteRequest) Handle() (interface{}, error) {
-	err := db.Query().Projects().Delete(r.ProjectId)
-	return nil, common.MaybeNewNotFoundOrInternalError("cannot delete project", err)
+func (r RestoreRequest) Handle(ctx context.Context) (interface{}, error) {
+	// trashed projects are invisible for CheckProject
+	role, err := db.Query().Members().GetRoleInDeleted(ctx, r.ProjectId, r.UserId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get member role", err)
+	}
+	if !role.Includes(common.RoleOwner) {
+		return nil, common.NewForbiddenError()
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := db.QueryWithTX(tx).Projects().Restore(ctx, r.ProjectId); err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot restore project", err)
+	}
+	after, err := db.QueryWithTX(tx).Projects().Get(ctx, r.ProjectId)
+	if err != nil {
+		return nil, common.NewInternalError("cannot get project", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		ResourceType: common.ResourceProject,
+		ResourceId:   r.ProjectId,
+		Action:       common.ActionRestore,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
 }
@@ -0,0 +1,88 @@
+package projects
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type TasksReportRequest struct {
+	common.Caller
+	ProjectId common.Id
+}
+
+// TasksReport writes CSV report with row per task of project accessible by caller of TasksReportRequest
+type TasksReport struct {
+	projectId common.Id
+	tx        db.TX
+}
+
+var tasksReportHeader = []string{
+	"id", "column", "position", "name", "description", "comments", "created_at", "updated_at",
+}
+
+// Handle opens db snapshot and checks that project exists in it, rows are read by TasksReport.Stream, see Export
+func (r TasksReportRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginSnapshot(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	if _, err := db.QueryWithTX(tx).Projects().Get(ctx, r.ProjectId); err != nil {
+		db.EndSnapshot(ctx, tx)
+		return nil, common.NewNotFoundOrInternalError("cannot get project", err)
+	}
+	return &TasksReport{projectId: r.ProjectId, tx: tx}, nil
+}
+
+func (tr *TasksReport) ContentType() string {
+	return "text/csv;charset=utf-8"
+}
+
+func (tr *TasksReport) Filename() string {
+	return fmt.Sprintf("project-%d-tasks.csv", tr.projectId)
+}
+
+// Stream writes tasks in board order as they are read from db and releases snapshot
+func (tr *TasksReport) Stream(ctx context.Context, w io.Writer) error {
+	defer db.EndSnapshot(ctx, tr.tx)
+	writer := csv.NewWriter(w)
+	if err := writer.Write(tasksReportHeader); err != nil {
+		return err
+	}
+	err := db.QueryWithTX(tr.tx).Tasks().ForEachInProject(ctx, tr.projectId, func(t common.TaskReportRow) error {
+		return writer.Write([]string{
+			strconv.Itoa(int(t.Id)),
+			escapeFormula(t.ColumnName),
+			strconv.Itoa(t.Position),
+			escapeFormula(t.Name),
+			escapeFormula(t.Description),
+			strconv.Itoa(t.CommentCount),
+			t.CreatedAt.UTC().Format(time.RFC3339),
+			t.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula prefixes user input which spreadsheets would evaluate as formula with quote, so it's shown as text
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
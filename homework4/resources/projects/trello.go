@@ -0,0 +1,273 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+// TrelloBoard contains fields of Trello board JSON export which are imported
+type TrelloBoard struct {
+	Name    string         `json:"name"`
+	Desc    string         `json:"desc"`
+	Lists   []TrelloList   `json:"lists"`
+	Cards   []TrelloCard   `json:"cards"`
+	Labels  []TrelloLabel  `json:"labels"`
+	Actions []TrelloAction `json:"actions"`
+}
+
+type TrelloList struct {
+	Id     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type TrelloCard struct {
+	Id       string     `json:"id"`
+	Name     string     `json:"name"`
+	Desc     string     `json:"desc"`
+	IdList   string     `json:"idList"`
+	Closed   bool       `json:"closed"`
+	Pos      float64    `json:"pos"`
+	Due      *time.Time `json:"due"`
+	IdLabels []string   `json:"idLabels"`
+}
+
+type TrelloLabel struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// TrelloAction is imported only if it's comment, other actions are ignored
+type TrelloAction struct {
+	Id   string    `json:"id"`
+	Type string    `json:"type"`
+	Date time.Time `json:"date"`
+	Data struct {
+		Text string `json:"text"`
+		Card struct {
+			Id string `json:"id"`
+		} `json:"card"`
+	} `json:"data"`
+}
+
+type TrelloImportRequest struct {
+	common.Caller
+	TrelloBoard
+}
+
+// TrelloImport maps Trello ids to ids of created resources
+type TrelloImport struct {
+	ProjectId common.Id            `json:"project_id"`
+	Labels    map[string]common.Id `json:"labels"`
+	Columns   map[string]common.Id `json:"columns"`
+	Tasks     map[string]common.Id `json:"tasks"`
+	Comments  map[string]common.Id `json:"comments"`
+}
+
+func (resource TrelloImport) GetId() common.Id {
+	return resource.ProjectId
+}
+
+// trelloColors maps Trello label colors to the ones used by Trello UI
+var trelloColors = map[string]string{
+	"green":  "#61bd4f",
+	"yellow": "#f2d600",
+	"orange": "#ff9f1a",
+	"red":    "#eb5a46",
+	"purple": "#c377e0",
+	"blue":   "#0079bf",
+	"sky":    "#00c2e0",
+	"lime":   "#51e898",
+	"pink":   "#ff78cb",
+	"black":  "#344563",
+}
+
+const trelloNoColor = "#b3bac5"
+
+// Handle imports open lists and cards in board order, labels, due dates and comments with their dates,
+// see importArchive. Too long texts are truncated and duplicate names are made unique.
+func (r TrelloImportRequest) Handle(ctx context.Context) (interface{}, error) {
+	archive, trelloIds := r.toArchive()
+	if err := common.Validate(archive); err != nil {
+		return TrelloImport{}, err
+	}
+	imported, err := importArchive(ctx, r.UserId, archive)
+	if err != nil {
+		return TrelloImport{}, err
+	}
+	result := TrelloImport{
+		ProjectId: imported.ProjectId,
+		Labels:    map[string]common.Id{},
+		Columns:   map[string]common.Id{},
+		Tasks:     map[string]common.Id{},
+		Comments:  map[string]common.Id{},
+	}
+	for archiveId, id := range imported.Labels {
+		result.Labels[trelloIds.labels[archiveId]] = id
+	}
+	for archiveId, id := range imported.Columns {
+		result.Columns[trelloIds.columns[archiveId]] = id
+	}
+	for archiveId, id := range imported.Tasks {
+		result.Tasks[trelloIds.tasks[archiveId]] = id
+	}
+	for archiveId, id := range imported.Comments {
+		result.Comments[trelloIds.comments[archiveId]] = id
+	}
+	return result, nil
+}
+
+// trelloIds maps archive ids to Trello ones
+type trelloIds struct {
+	labels, columns, tasks, comments map[common.Id]string
+}
+
+func (b TrelloBoard) toArchive() (common.ProjectArchive, trelloIds) {
+	ids := trelloIds{
+		labels:   map[common.Id]string{},
+		columns:  map[common.Id]string{},
+		tasks:    map[common.Id]string{},
+		comments: map[common.Id]string{},
+	}
+	archive := common.ProjectArchive{
+		ProjectArchiveHeader: common.ProjectArchiveHeader{
+			Version: common.ArchiveVersion,
+			Project: common.ArchivedProject{
+				ProjectSettableFields: common.ProjectSettableFields{
+					Name:        truncate(nonEmpty(b.Name), 500),
+					Description: truncate(b.Desc, 1000),
+				},
+			},
+			Labels: []common.Label{},
+		},
+		Columns: []common.ArchivedColumn{},
+	}
+
+	labelsIds := map[string]common.Id{}
+	labelsNames := map[string]bool{}
+	for _, l := range b.Labels {
+		id := common.Id(len(labelsIds) + 1)
+		labelsIds[l.Id], ids.labels[id] = id, l.Id
+		name := l.Name
+		if name == "" {
+			name = l.Color
+		}
+		color, ok := trelloColors[l.Color]
+		if !ok {
+			color = trelloNoColor
+		}
+		archive.Labels = append(archive.Labels, common.Label{
+			Id: id,
+			LabelSettableFields: common.LabelSettableFields{
+				Name:  uniqueName(nonEmpty(name), 250, labelsNames),
+				Color: color,
+			},
+		})
+	}
+
+	comments := map[string][]common.ArchivedComment{}
+	for i, a := range b.Actions {
+		if a.Type != "commentCard" || a.Data.Text == "" {
+			continue
+		}
+		id := common.Id(i + 1)
+		ids.comments[id] = a.Id
+		comments[a.Data.Card.Id] = append(comments[a.Data.Card.Id], common.ArchivedComment{
+			Id:                    id,
+			CommentSettableFields: common.CommentSettableFields{Text: truncate(a.Data.Text, 5000)},
+			CreatedAt:             a.Date,
+		})
+	}
+
+	lists := make([]TrelloList, 0, len(b.Lists))
+	for _, l := range b.Lists {
+		if !l.Closed {
+			lists = append(lists, l)
+		}
+	}
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+	cards := make([]TrelloCard, 0, len(b.Cards))
+	for _, c := range b.Cards {
+		if !c.Closed {
+			cards = append(cards, c)
+		}
+	}
+	sort.SliceStable(cards, func(i, j int) bool { return cards[i].Pos < cards[j].Pos })
+
+	columnsNames := map[string]bool{}
+	columnsIndexes := map[string]int{}
+	for i, l := range lists {
+		id := common.Id(i + 1)
+		ids.columns[id] = l.Id
+		columnsIndexes[l.Id] = i
+		archive.Columns = append(archive.Columns, common.ArchivedColumn{
+			Id:                   id,
+			ColumnSettableFields: common.ColumnSettableFields{Name: uniqueName(nonEmpty(l.Name), 250, columnsNames)},
+			Tasks:                []common.ArchivedTask{},
+		})
+	}
+	for i, c := range cards {
+		columnIndex, ok := columnsIndexes[c.IdList]
+		if !ok {
+			continue
+		}
+		id := common.Id(i + 1)
+		ids.tasks[id] = c.Id
+		task := common.ArchivedTask{
+			Id: id,
+			TaskSettableFields: common.TaskSettableFields{
+				Name:        truncate(nonEmpty(c.Name), 500),
+				Description: truncate(c.Desc, 5000),
+			},
+			LabelIds: []common.Id{},
+			Comments: comments[c.Id],
+		}
+		if c.Due != nil {
+			dueDate := c.Due.UTC().Format("2006-01-02")
+			task.DueDate = &dueDate
+		}
+		attached := map[common.Id]bool{}
+		for _, trelloLabelId := range c.IdLabels {
+			if labelId, ok := labelsIds[trelloLabelId]; ok && !attached[labelId] {
+				task.LabelIds = append(task.LabelIds, labelId)
+				attached[labelId] = true
+			}
+		}
+		archive.Columns[columnIndex].Tasks = append(archive.Columns[columnIndex].Tasks, task)
+	}
+	return archive, ids
+}
+
+func nonEmpty(name string) string {
+	if name == "" {
+		return "untitled"
+	}
+	return name
+}
+
+// truncate truncates s to max runes, since validation limits are in runes
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
+
+// uniqueName truncates name to max runes and appends number to it if it's already used,
+// name is truncated further to fit number within max
+func uniqueName(name string, max int, used map[string]bool) string {
+	unique := truncate(name, max)
+	for i := 2; used[unique]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		unique = truncate(name, max-utf8.RuneCountInString(suffix)) + suffix
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,94 @@
+package ranks
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+// maxLength is rank length after which ranks are rebalanced, ranks grow
+// when resources are repeatedly placed between adjacent ones or at the end
+const maxLength = 10
+
+type RebalanceRequest struct {
+	common.Caller
+	ProjectId common.Id
+}
+
+// Handle rebalances ranks of project columns and tasks of every column regardless of their length
+func (r RebalanceRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	columnsIds, err := rebalanceColumns(ctx, tx, r.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	for _, columnId := range columnsIds {
+		if err := rebalanceTasks(ctx, tx, columnId); err != nil {
+			return nil, err
+		}
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// RebalanceColumnsIfNeeded rewrites ranks of all project columns, trashed ones included,
+// if rank assigned to column is too long. It must be called within the same transaction
+// after rank is assigned, so changed order is taken into account.
+func RebalanceColumnsIfNeeded(ctx context.Context, tx db.TX, projectId common.Id, rank common.Rank) error {
+	if len(rank) <= maxLength {
+		return nil
+	}
+	_, err := rebalanceColumns(ctx, tx, projectId)
+	return err
+}
+
+// RebalanceTasksIfNeeded rewrites ranks of all column tasks if rank assigned to task is too long,
+// see RebalanceColumnsIfNeeded
+func RebalanceTasksIfNeeded(ctx context.Context, tx db.TX, columnId common.Id, rank common.Rank) error {
+	if len(rank) <= maxLength {
+		return nil
+	}
+	return rebalanceTasks(ctx, tx, columnId)
+}
+
+func rebalanceColumns(ctx context.Context, tx db.TX, projectId common.Id) ([]common.Id, error) {
+	ids, err := db.QueryWithTX(tx).Columns().GetAndBlockIds(ctx, projectId)
+	if err != nil {
+		return ids, common.NewInternalError("cannot get columns ids", err)
+	}
+	err = db.QueryWithTX(tx).Columns().UpdateRanks(ctx, ids, common.CalculateRanksEvenly(len(ids)))
+	return ids, common.MaybeNewInternalError("cannot update columns ranks", err)
+}
+
+func rebalanceTasks(ctx context.Context, tx db.TX, columnId common.Id) error {
+	ids, err := db.QueryWithTX(tx).Tasks().GetAndBlockIdsByColumn(ctx, columnId)
+	if err != nil {
+		return common.NewInternalError("cannot get tasks ids", err)
+	}
+	err = db.QueryWithTX(tx).Tasks().UpdateRanks(ctx, ids, common.CalculateRanksEvenly(len(ids)))
+	return common.MaybeNewInternalError("cannot update tasks ranks", err)
+}
+
+// RebalanceItemsIfNeeded rewrites ranks of all checklist items if rank assigned to item is too long,
+// see RebalanceColumnsIfNeeded. Checklist must be blocked by caller.
+func RebalanceItemsIfNeeded(ctx context.Context, tx db.TX, checklistId common.Id, rank common.Rank) error {
+	if len(rank) <= maxLength {
+		return nil
+	}
+	ids, err := db.QueryWithTX(tx).Checklists().GetItemsIds(ctx, checklistId)
+	if err != nil {
+		return common.NewInternalError("cannot get checklist items ids", err)
+	}
+	err = db.QueryWithTX(tx).Checklists().UpdateItemsRanks(ctx, ids, common.CalculateRanksEvenly(len(ids)))
+	return common.MaybeNewInternalError("cannot update checklist items ranks", err)
+}
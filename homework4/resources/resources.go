@@ -1,12 +1,62 @@
 package resources
 
-import "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/tracing"
+)
 
 type Request interface {
+	// Handle should stop as soon as ctx is done, e.g. when client disconnects or request times out.
 	// If error is not nil, first value should be ignored
-	Handle() (interface{}, error)
+	Handle(ctx context.Context) (interface{}, error)
+}
+
+// Handle handles request within span named by request type, e.g. tasks.UpdatePositionRequest,
+// regardless of whether request is passed by pointer, only internal errors mark span as failed
+func Handle(ctx context.Context, req Request) (interface{}, error) {
+	ctx, span := tracing.Tracer.Start(ctx, reflect.Indirect(reflect.ValueOf(req)).Type().String())
+	resp, err := req.Handle(ctx)
+	var genError common.Error
+	if errors.As(err, &genError) && genError.Type != common.InternalError {
+		span.End()
+	} else {
+		tracing.End(span, err)
+	}
+	return resp, err
 }
 
 type Resource interface {
 	GetId() common.Id
 }
+
+// Stream is returned by requests which response is written to client while it's read from db,
+// instead of being marshaled as a whole
+type Stream interface {
+	ContentType() string
+	// name of file which is suggested to client for saving response
+	Filename() string
+	// Stream is always called once response is returned by Handle, it releases resources acquired by Handle,
+	// e.g. db snapshot, so errors which can be reported with status should be returned by Handle instead
+	Stream(ctx context.Context, w io.Writer) error
+}
+
+// Authenticated is implemented by requests which are handled on behalf of user
+type Authenticated interface {
+	SetCaller(userId common.Id)
+}
+
+// Versioned is implemented by resources which have version exposed as ETag,
+// 0 version means that resource representation is not versioned
+type Versioned interface {
+	GetVersion() common.Version
+}
+
+// Conditional is implemented by requests which honour If-Match header
+type Conditional interface {
+	SetPrecondition(ifMatch common.Version)
+}
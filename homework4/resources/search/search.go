@@ -0,0 +1,26 @@
+package search
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+type Request struct {
+	common.Caller
+	Query string `validate:"min=1,max=500"`
+	// if 0, all projects where caller is a member are searched through
+	ProjectId common.Id
+	Limit     int `validate:"min=1,max=100"`
+}
+
+func (r Request) Handle(ctx context.Context) (interface{}, error) {
+	if r.ProjectId != 0 {
+		if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+			return []common.SearchHit{}, err
+		}
+	}
+	hits, err := db.Query().Search().Search(ctx, r.UserId, r.Query, r.ProjectId, r.Limit)
+	return hits, common.MaybeNewInternalError("cannot search", err)
+}
@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+const (
+	OpMove   = "move"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+type BatchRequest struct {
+	rcommon.Caller
+	Operations []BatchOperation `json:"operations" validate:"min=1,max=100,dive"`
+}
+
+type BatchOperation struct {
+	Op     string     `json:"op" validate:"oneof=move update delete" enums:"move,update,delete"`
+	TaskId rcommon.Id `json:"task_id" validate:"min=1,nefield=AfterTaskId" swaggertype:"primitive,integer"`
+	// expected version of task like in If-Match header, 0 matches any
+	Version rcommon.Version `json:"version" swaggertype:"primitive,integer"`
+	// required for move
+	NewColumnId rcommon.Id `json:"new_column_id" swaggertype:"primitive,integer"`
+	// used only by move, consecutive moves with the same new_column_id and after_task_id
+	// place tasks one after another in the listed order
+	AfterTaskId rcommon.Id `json:"after_task_id" swaggertype:"primitive,integer"`
+	// required for update
+	Fields *rcommon.TaskSettableFields `json:"fields"`
+}
+
+// BatchResult contains state of task after operation, task is null for deleted ones
+type BatchResult struct {
+	Op     string        `json:"op" enums:"move,update,delete"`
+	TaskId rcommon.Id    `json:"task_id" swaggertype:"primitive,integer"`
+	Task   *rcommon.Task `json:"task"`
+}
+
+// Handle applies operations in order within single transaction,
+// nothing is applied if any of operations fails
+func (r BatchRequest) Handle(ctx context.Context) (interface{}, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	results := make([]BatchResult, 0, len(r.Operations))
+	for i, op := range r.Operations {
+		if i > 0 && op.follows(r.Operations[i-1]) {
+			op.AfterTaskId = r.Operations[i-1].TaskId
+		}
+		result, err := op.apply(ctx, tx, r.UserId)
+		if err != nil {
+			return nil, newOperationError(i, err)
+		}
+		results = append(results, result)
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return results, nil
+}
+
+// follows reports whether operation continues contiguous block of moves started by previous one
+func (op BatchOperation) follows(prev BatchOperation) bool {
+	return op.Op == OpMove && prev.Op == OpMove && op.TaskId != prev.TaskId &&
+		op.NewColumnId == prev.NewColumnId && op.AfterTaskId == prev.AfterTaskId
+}
+
+func (op BatchOperation) apply(ctx context.Context, tx db.TX, userId rcommon.Id) (BatchResult, error) {
+	result := BatchResult{Op: op.Op, TaskId: op.TaskId}
+	precondition := rcommon.Precondition{IfMatch: op.Version}
+	var err error
+	switch op.Op {
+	case OpMove:
+		if op.NewColumnId == 0 {
+			return result, rcommon.NewUnprocessableError("new_column_id is required for move")
+		}
+		position := UpdatePositionRequestBody{NewColumnId: op.NewColumnId, AfterTaskId: op.AfterTaskId}
+		err = move(ctx, tx, userId, op.TaskId, position, precondition)
+	case OpUpdate:
+		if op.Fields == nil {
+			return result, rcommon.NewUnprocessableError("fields are required for update")
+		}
+		err = update(ctx, tx, userId, op.TaskId, *op.Fields, precondition)
+	case OpDelete:
+		return result, remove(ctx, tx, userId, op.TaskId, precondition)
+	}
+	if err != nil {
+		return result, err
+	}
+	task, err := db.QueryWithTX(tx).Tasks().Get(ctx, op.TaskId)
+	if err != nil {
+		return result, rcommon.NewInternalError("cannot get task", err)
+	}
+	result.Task = &task
+	return result, nil
+}
+
+// newOperationError prefixes description of error with index of failed operation
+func newOperationError(i int, err error) error {
+	var e rcommon.Error
+	if errors.As(err, &e) {
+		e.Description = fmt.Sprintf("operation %d: %v", i, e.Description)
+		return e
+	}
+	return err
+}
@@ -1,33 +1,58 @@
 package tasks
 
 import (
+	"context"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/activity"
 	rcommon "github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/ranks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/wip"
 )
 
 type CreateRequest struct {
+	rcommon.Caller
 	ProjectId rcommon.Id
 	ColumnId  rcommon.Id
 	rcommon.TaskSettableFields
 }
 
 type ReadRequest struct {
+	rcommon.Caller
 	TaskId   rcommon.Id
 	Expanded bool
 }
 
 type UpdateRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
 	TaskId rcommon.Id
 	rcommon.TaskSettableFields
 }
 
+type PatchRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
+	rcommon.MergePatch
+	TaskId rcommon.Id
+}
+
 type DeleteRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
+	TaskId rcommon.Id
+}
+
+type RestoreRequest struct {
+	rcommon.Caller
 	TaskId rcommon.Id
 }
 
 type UpdatePositionRequest struct {
+	rcommon.Caller
+	rcommon.Precondition
 	TaskId rcommon.Id `validate:"nefield=UpdatePositionRequestBody.AfterTaskId"`
 	UpdatePositionRequestBody
 }
@@ -37,97 +62,289 @@ type UpdatePositionRequestBody struct {
 	AfterTaskId rcommon.Id `json:"after_task_id" swaggertype:"primitive,integer"`
 }
 
-func (r CreateRequest) Handle() (interface{}, error) {
-	if _, err := db.Query().Columns().Get(r.ProjectId, r.ColumnId); err != nil {
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, rcommon.RoleEditor); err != nil {
+		return rcommon.Task{}, err
+	}
+	if _, err := db.Query().Columns().Get(ctx, r.ProjectId, r.ColumnId); err != nil {
 		return rcommon.Task{}, rcommon.NewNotFoundOrInternalError("cannot get column", err)
 	}
-	tx, err := db.Begin()
-	defer db.Rollback(tx)
+	if err := validateAssignee(ctx, r.ProjectId, r.AssigneeId); err != nil {
+		return rcommon.Task{}, err
+	}
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return rcommon.Task{}, rcommon.NewInternalError("cannot begin transaction", err)
 	}
-	defer db.Rollback(tx)
-	maxRank, err := db.QueryWithTX(tx).Tasks().GetAndBlockMaxRankByColumn(r.ColumnId)
+	defer db.Rollback(ctx, tx)
+	if err := wip.CheckLimit(ctx, tx, r.ColumnId, 1); err != nil {
+		return rcommon.Task{}, err
+	}
+	maxRank, err := db.QueryWithTX(tx).Tasks().GetAndBlockMaxRankByColumn(ctx, r.ColumnId)
 	if common.IsNoRowsError(err) {
 		maxRank = ""
 	} else if err != nil {
 		return rcommon.Task{}, rcommon.NewInternalError("cannot get max rank", err)
 	}
 	maxRank = rcommon.CalculateRankHigher(maxRank)
-	task, err := db.QueryWithTX(tx).Tasks().Create(r.ProjectId, r.ColumnId, r.Name, r.Description, maxRank)
+	task, err := db.QueryWithTX(tx).Tasks().Create(ctx, r.ProjectId, r.ColumnId, r.TaskSettableFields, maxRank)
 	if err != nil {
 		return task, rcommon.NewInternalError("cannot create task", err)
 	}
-	if err := db.Commit(tx); err != nil {
+	if err := ranks.RebalanceTasksIfNeeded(ctx, tx, r.ColumnId, maxRank); err != nil {
+		return rcommon.Task{}, err
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    r.ProjectId,
+		TaskId:       task.Id,
+		ResourceType: rcommon.ResourceTask,
+		ResourceId:   task.Id,
+		Action:       rcommon.ActionCreate,
+		After:        task,
+	})
+	if err != nil {
+		return rcommon.Task{}, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
 		return rcommon.Task{}, rcommon.NewInternalError("cannot commit transaction", err)
 	}
 	return task, nil
 }
 
-func (r ReadRequest) Handle() (interface{}, error) {
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if _, err := access.CheckTask(ctx, r.UserId, r.TaskId, rcommon.RoleViewer); err != nil {
+		return rcommon.Task{}, err
+	}
 	var task resources.Resource
 	var err error
 	if r.Expanded {
-		task, err = db.Query().Tasks().GetExpanded(r.TaskId)
+		task, err = db.Query().Tasks().GetExpanded(ctx, r.TaskId)
 	} else {
-		task, err = db.Query().Tasks().Get(r.TaskId)
+		task, err = db.Query().Tasks().Get(ctx, r.TaskId)
 	}
 	return task, rcommon.MaybeNewNotFoundOrInternalError("cannot read task", err)
 }
 
-func (r UpdateRequest) Handle() (interface{}, error) {
-	err := db.Query().Tasks().Update(r.TaskId, r.Name, r.Description)
-	return nil, rcommon.MaybeNewNotFoundOrInternalError("cannot update task", err)
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := update(ctx, tx, r.UserId, r.TaskId, r.TaskSettableFields, r.Precondition); err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func update(ctx context.Context, tx db.TX, userId, taskId rcommon.Id, fields rcommon.TaskSettableFields, p rcommon.Precondition) error {
+	before, err := getForEdit(ctx, tx, userId, taskId)
+	if err != nil {
+		return err
+	}
+	if err := p.Check(before.Version); err != nil {
+		return err
+	}
+	if err := validateAssignee(ctx, before.ProjectId, fields.AssigneeId); err != nil {
+		return err
+	}
+	if err := db.QueryWithTX(tx).Tasks().Update(ctx, taskId, fields, p.IfMatch); err != nil {
+		return p.NewWriteError("cannot update task", err)
+	}
+	after := before
+	after.TaskSettableFields = fields
+	return activity.Record(ctx, tx, userId, activity.Change{
+		ProjectId:    before.ProjectId,
+		TaskId:       taskId,
+		ResourceType: rcommon.ResourceTask,
+		ResourceId:   taskId,
+		Action:       rcommon.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
 }
 
-func (r DeleteRequest) Handle() (interface{}, error) {
-	err := db.Query().Tasks().Delete(r.TaskId)
-	return nil, rcommon.MaybeNewNotFoundOrInternalError("cannot delete task", err)
+// getForEdit reads task within transaction, so changes made by preceding operations
+// of the same transaction are visible, and checks that user can edit it
+func getForEdit(ctx context.Context, tx db.TX, userId, taskId rcommon.Id) (rcommon.Task, error) {
+	task, err := db.QueryWithTX(tx).Tasks().Get(ctx, taskId)
+	if err != nil {
+		return task, rcommon.NewNotFoundOrInternalError("cannot get task", err)
+	}
+	return task, access.CheckProject(ctx, userId, task.ProjectId, rcommon.RoleEditor)
 }
 
-func (r UpdatePositionRequest) Handle() (interface{}, error) {
-	if err := validatePositionUpdate(r); err != nil {
+func (r PatchRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := access.CheckTask(ctx, r.UserId, r.TaskId, rcommon.RoleEditor)
+	if err != nil {
 		return nil, err
 	}
-	tx, err := db.Begin()
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return nil, rcommon.NewInternalError("cannot begin transaction", err)
 	}
-	defer db.Rollback(tx)
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Tasks().Get(ctx, r.TaskId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get task", err)
+	}
+	if err := r.Check(before.Version); err != nil {
+		return nil, err
+	}
+	after := before
+	values, err := r.Apply(&after.TaskSettableFields)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+	if _, ok := values["assignee_id"]; ok {
+		if err := validateAssignee(ctx, task.ProjectId, after.AssigneeId); err != nil {
+			return nil, err
+		}
+	}
+	if err := db.QueryWithTX(tx).Tasks().Patch(ctx, r.TaskId, values, r.IfMatch); err != nil {
+		return nil, r.NewWriteError("cannot patch task", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: rcommon.ResourceTask,
+		ResourceId:   r.TaskId,
+		Action:       rcommon.ActionUpdate,
+		Before:       before,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func validateAssignee(ctx context.Context, projectId rcommon.Id, assigneeId *rcommon.Id) error {
+	if assigneeId == nil {
+		return nil
+	}
+	role, err := db.Query().Members().GetRole(ctx, projectId, *assigneeId)
+	if err != nil {
+		return rcommon.NewNotFoundOrInternalError("cannot get assignee role", err)
+	}
+	if role == "" {
+		return rcommon.NewConflictError("assignee must be a member of the project")
+	}
+	return nil
+}
+
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := remove(ctx, tx, r.UserId, r.TaskId, r.Precondition); err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+// remove moves task to trash, its attachments are kept until task is purged from trash, so it can be restored with them
+func remove(ctx context.Context, tx db.TX, userId, taskId rcommon.Id, p rcommon.Precondition) error {
+	before, err := getForEdit(ctx, tx, userId, taskId)
+	if err != nil {
+		return err
+	}
+	if err := p.Check(before.Version); err != nil {
+		return err
+	}
+	if err := db.QueryWithTX(tx).Tasks().Delete(ctx, taskId, p.IfMatch); err != nil {
+		return p.NewWriteError("cannot delete task", err)
+	}
+	return activity.Record(ctx, tx, userId, activity.Change{
+		ProjectId:    before.ProjectId,
+		TaskId:       taskId,
+		ResourceType: rcommon.ResourceTask,
+		ResourceId:   taskId,
+		Action:       rcommon.ActionDelete,
+		Before:       before,
+	})
+}
+
+func (r UpdatePositionRequest) Handle(ctx context.Context) (interface{}, error) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := move(ctx, tx, r.UserId, r.TaskId, r.UpdatePositionRequestBody, r.Precondition); err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func move(ctx context.Context, tx db.TX, userId, taskId rcommon.Id, position UpdatePositionRequestBody, p rcommon.Precondition) error {
+	task, err := getForEdit(ctx, tx, userId, taskId)
+	if err != nil {
+		return err
+	}
+	if err := p.Check(task.Version); err != nil {
+		return err
+	}
+	if err := validatePositionUpdate(ctx, task, position); err != nil {
+		return err
+	}
+	if task.ColumnId != position.NewColumnId {
+		if err := wip.CheckLimit(ctx, tx, position.NewColumnId, 1); err != nil {
+			return err
+		}
+	}
 	var prevRank rcommon.Rank = ""
-	if r.AfterTaskId > 0 {
-		prevRank, err = db.QueryWithTX(tx).Tasks().GetAndBlockRank(r.NewColumnId, r.AfterTaskId)
+	if position.AfterTaskId > 0 {
+		prevRank, err = db.QueryWithTX(tx).Tasks().GetAndBlockRank(ctx, position.NewColumnId, position.AfterTaskId)
 		if common.IsNoRowsError(err) {
-			return nil, rcommon.NewConflictError("task specified by after_task_id not found in target column")
+			return rcommon.NewConflictError("task specified by after_task_id not found in target column")
 		} else if err != nil {
-			return nil, rcommon.NewInternalError("cannot get previous task rank", err)
+			return rcommon.NewInternalError("cannot get previous task rank", err)
 		}
 	}
 	var newRank rcommon.Rank
-	nextRank, err := db.QueryWithTX(tx).Tasks().GetNextRank(r.NewColumnId, prevRank)
+	nextRank, err := db.QueryWithTX(tx).Tasks().GetNextRank(ctx, position.NewColumnId, prevRank)
 	if err == nil {
 		newRank = rcommon.CalculateRankBetween(prevRank, nextRank)
 	} else if common.IsNoRowsError(err) {
 		newRank = rcommon.CalculateRankHigher(prevRank)
 	} else if err != nil {
-		return nil, rcommon.NewInternalError("cannot get next task rank", err)
+		return rcommon.NewInternalError("cannot get next task rank", err)
 	}
-	if err := db.QueryWithTX(tx).Tasks().UpdatePosition(r.TaskId, r.NewColumnId, newRank); err != nil {
-		return nil, rcommon.NewNotFoundOrInternalError("cannot update task position", err)
+	if err := db.QueryWithTX(tx).Tasks().UpdatePosition(ctx, taskId, position.NewColumnId, newRank, p.IfMatch); err != nil {
+		return p.NewWriteError("cannot update task position", err)
 	}
-	if err := db.Commit(tx); err != nil {
-		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	if err := ranks.RebalanceTasksIfNeeded(ctx, tx, position.NewColumnId, newRank); err != nil {
+		return err
 	}
-	return nil, nil
+	return activity.Record(ctx, tx, userId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       taskId,
+		ResourceType: rcommon.ResourceTask,
+		ResourceId:   taskId,
+		Action:       rcommon.ActionMove,
+		Before:       activity.TaskPosition{ColumnId: task.ColumnId},
+		After:        activity.TaskPosition{ColumnId: position.NewColumnId, AfterTaskId: position.AfterTaskId},
+	})
 }
 
-func validatePositionUpdate(r UpdatePositionRequest) error {
-	task, err := db.Query().Tasks().Get(r.TaskId)
-	if err != nil {
-		return rcommon.NewNotFoundOrInternalError("cannot get task", err)
-	}
-	if task.ColumnId != r.NewColumnId {
-		_, err := db.Query().Columns().Get(task.ProjectId, r.NewColumnId)
+func validatePositionUpdate(ctx context.Context, task rcommon.Task, position UpdatePositionRequestBody) error {
+	if task.ColumnId != position.NewColumnId {
+		_, err := db.Query().Columns().Get(ctx, task.ProjectId, position.NewColumnId)
 		if common.IsNoRowsError(err) {
 			return rcommon.NewConflictError("column specified by new_column_id not found in target project")
 		} else if err != nil {
@@ -136,3 +353,45 @@ func validatePositionUpdate(r UpdatePositionRequest) error {
 	}
 	return nil
 }
+
+// Handle restores task with comments deleted along with it, task stays in the column it was in
+// when deleted, or in the successor column if that one was deleted afterwards, unless the column is full
+func (r RestoreRequest) Handle(ctx context.Context) (interface{}, error) {
+	task, err := db.Query().Tasks().GetDeleted(ctx, r.TaskId)
+	if err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot get task", err)
+	}
+	if err := access.CheckProject(ctx, r.UserId, task.ProjectId, rcommon.RoleEditor); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	if err := wip.CheckLimit(ctx, tx, task.ColumnId, 1); err != nil {
+		return nil, err
+	}
+	if err := db.QueryWithTX(tx).Tasks().Restore(ctx, r.TaskId); err != nil {
+		return nil, rcommon.NewNotFoundOrInternalError("cannot restore task", err)
+	}
+	after, err := db.QueryWithTX(tx).Tasks().Get(ctx, r.TaskId)
+	if err != nil {
+		return nil, rcommon.NewInternalError("cannot get task", err)
+	}
+	err = activity.Record(ctx, tx, r.UserId, activity.Change{
+		ProjectId:    task.ProjectId,
+		TaskId:       r.TaskId,
+		ResourceType: rcommon.ResourceTask,
+		ResourceId:   r.TaskId,
+		Action:       rcommon.ActionRestore,
+		After:        after,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, rcommon.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
@@ -0,0 +1,96 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/storage"
+	"go.uber.org/zap"
+)
+
+const defaultRetention = 30 * 24 * time.Hour
+
+const purgeInterval = time.Hour
+
+const purgeTimeout = 10 * time.Minute
+
+type ReadRequest struct {
+	common.Caller
+	// if 0, trash of all projects where caller is a member is listed
+	ProjectId common.Id
+	Limit     int `validate:"min=1,max=100"`
+}
+
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if r.ProjectId != 0 {
+		if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleViewer); err != nil {
+			return []common.TrashItem{}, err
+		}
+	}
+	items, err := db.Query().Trash().Get(ctx, r.UserId, r.ProjectId, r.Limit)
+	return items, common.MaybeNewInternalError("cannot get trash", err)
+}
+
+// retention is period after which resources in trash are purged
+var retention = defaultRetention
+
+// Init sets retention period from TRASH_RETENTION env variable, e.g. 168h, 30 days by default
+func Init() error {
+	if value := os.Getenv("TRASH_RETENTION"); value != "" {
+		var err error
+		if retention, err = time.ParseDuration(value); err != nil || retention <= 0 {
+			return fmt.Errorf("invalid TRASH_RETENTION %q", value)
+		}
+	}
+	return nil
+}
+
+// Run purges resources which are in trash longer than retention period until ctx is done,
+// it should be called after db initialization
+func Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		purge(retention)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func purge(retention time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), purgeTimeout)
+	defer cancel()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		logger.Zap.Error("cannot begin transaction", zap.Error(err))
+		return
+	}
+	defer db.Rollback(ctx, tx)
+	blobs, err := db.QueryWithTX(tx).Attachments().DeleteOfPurgedTasks(ctx, retention)
+	if err != nil {
+		logger.Zap.Error("cannot delete attachments of purged tasks", zap.Error(err))
+		return
+	}
+	purged, err := db.QueryWithTX(tx).Trash().Purge(ctx, retention)
+	if err != nil {
+		logger.Zap.Error("cannot purge trash", zap.Error(err))
+		return
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		logger.Zap.Error("cannot commit transaction", zap.Error(err))
+		return
+	}
+	storage.DeleteAll(ctx, blobs)
+	if purged > 0 {
+		logger.Zap.Info("trash purged", zap.Int64("rows", purged))
+	}
+}
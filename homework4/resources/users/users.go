@@ -0,0 +1,89 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	dbCommon "github.com/AndreyKlimchuk/golang-learning/homework4/db/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const tokenLength = 32
+
+type CreateRequest struct {
+	common.Credentials
+}
+
+type ReadRequest struct {
+	common.Caller
+	TargetUserId common.Id
+}
+
+type CreateTokenRequest struct {
+	common.Credentials
+}
+
+type AuthenticateRequest struct {
+	Token string
+}
+
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	_, _, err := db.Query().Users().GetByName(ctx, r.Name)
+	if err == nil {
+		return common.User{}, common.NewConflictError("user with same name exists")
+	} else if !dbCommon.IsNoRowsError(err) {
+		return common.User{}, common.NewInternalError("cannot get user by name", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(r.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return common.User{}, common.NewInternalError("cannot hash password", err)
+	}
+	user, err := db.Query().Users().Create(ctx, r.Name, string(hash))
+	return user, common.MaybeNewInternalError("cannot create user", err)
+}
+
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	user, err := db.Query().Users().Get(ctx, r.TargetUserId)
+	return user, common.MaybeNewNotFoundOrInternalError("cannot get user", err)
+}
+
+func (r CreateTokenRequest) Handle(ctx context.Context) (interface{}, error) {
+	user, passwordHash, err := db.Query().Users().GetByName(ctx, r.Name)
+	if dbCommon.IsNoRowsError(err) {
+		return common.Token{}, common.NewUnauthorizedError()
+	} else if err != nil {
+		return common.Token{}, common.NewInternalError("cannot get user by name", err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(r.Password)); err != nil {
+		return common.Token{}, common.NewUnauthorizedError()
+	}
+	token := make([]byte, tokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return common.Token{}, common.NewInternalError("cannot generate token", err)
+	}
+	t := common.Token{Token: hex.EncodeToString(token)}
+	err = db.Query().Users().CreateToken(ctx, user.Id, hashToken(t.Token))
+	return t, common.MaybeNewInternalError("cannot create token", err)
+}
+
+// Handle returns id of user which owns the token
+func (r AuthenticateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if r.Token == "" {
+		return common.Id(0), common.NewUnauthorizedError()
+	}
+	userId, err := db.Query().Users().GetIdByToken(ctx, hashToken(r.Token))
+	if dbCommon.IsNoRowsError(err) {
+		return common.Id(0), common.NewUnauthorizedError()
+	}
+	return userId, common.MaybeNewInternalError("cannot get user by token", err)
+}
+
+// only hashes of tokens are stored, so leaked database doesn't give access to api
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,127 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// errForbiddenAddress is returned when webhook url resolves to address of internal network
+var errForbiddenAddress = errors.New("forbidden address")
+
+// allowPrivateNetworks permits deliveries to internal addresses, it's meant for local development and tests only
+var allowPrivateNetworks = false
+
+// nonPublicNetworks are private, shared and reserved networks, which aren't covered by net.IP methods
+var nonPublicNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"fc00::/7",
+)
+
+// client checks address after resolution, right before connecting, so host can't resolve
+// to another address between check and connection. Redirects aren't followed, since their targets
+// aren't checked by owner of webhook.
+var client = &http.Client{
+	Timeout: requestTimeout,
+	Transport: &http.Transport{
+		// proxy would be connected instead of webhook host, so its address would be checked instead
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: checkAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        batchSize,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Init permits deliveries to internal addresses if WEBHOOKS_ALLOW_PRIVATE_NETWORKS env variable is true
+func Init() error {
+	if value := os.Getenv("WEBHOOKS_ALLOW_PRIVATE_NETWORKS"); value != "" {
+		var err error
+		if allowPrivateNetworks, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("invalid WEBHOOKS_ALLOW_PRIVATE_NETWORKS %q", value)
+		}
+	}
+	return nil
+}
+
+// IsPublicAddress returns false for loopback, private, link-local, multicast, unspecified and reserved addresses
+func IsPublicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAddress is called by dialer with resolved address
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	if allowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicAddress(ip) {
+		return errForbiddenAddress
+	}
+	return nil
+}
+
+// isForbiddenHost returns true if url host is literal IP address which can't be connected,
+// hostnames are checked only when they are resolved on delivery
+func isForbiddenHost(host string) bool {
+	ip := net.ParseIP(host)
+	return !allowPrivateNetworks && ip != nil && !IsPublicAddress(ip)
+}
+
+// publicError returns description of delivery error which is shown to project owner,
+// details of connection errors aren't disclosed, since they reveal internal network
+func publicError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errForbiddenAddress):
+		return "destination address is not allowed"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	case errors.As(err, &responseStatusError{}):
+		return err.Error()
+	default:
+		return "request failed"
+	}
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
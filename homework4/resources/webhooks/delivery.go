@@ -0,0 +1,169 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	dbwebhooks "github.com/AndreyKlimchuk/golang-learning/homework4/db/webhooks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"go.uber.org/zap"
+)
+
+const (
+	SignatureHeader = "X-Gorello-Signature"
+	EventHeader     = "X-Gorello-Event"
+	DeliveryHeader  = "X-Gorello-Delivery"
+)
+
+const pollInterval = time.Second
+
+// batchSize is max number of deliveries sent concurrently
+const batchSize = 20
+
+const requestTimeout = 10 * time.Second
+
+// lease must be longer than requestTimeout, so delivery isn't claimed again while being sent
+const lease = 3 * requestTimeout
+
+// delivery is failed after maxAttempts, delays between attempts grow exponentially from minRetryDelay
+const (
+	maxAttempts   = 10
+	minRetryDelay = 10 * time.Second
+	maxRetryDelay = time.Hour
+)
+
+// Payload is body of delivery request, timestamp is covered by signature,
+// so receivers can reject captured deliveries which are sent again
+type Payload struct {
+	Event string `json:"event"`
+	// unix time of attempt in seconds
+	Timestamp int64           `json:"timestamp"`
+	Activity  common.Activity `json:"activity"`
+}
+
+type responseStatusError struct {
+	status int
+}
+
+func (e responseStatusError) Error() string {
+	return fmt.Sprintf("unexpected response status %v", e.status)
+}
+
+// Run sends enqueued deliveries until ctx is done, it should be called after db initialization.
+// Deliveries which are being sent when ctx is done are sent to the end.
+func Run(ctx context.Context) {
+	for {
+		// full batch means there may be more due deliveries
+		if deliverDue() == batchSize && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func deliverDue() int {
+	// deliveries which aren't sent until lease ends may be claimed again, so their sending is aborted
+	ctx, cancel := context.WithTimeout(context.Background(), lease)
+	defer cancel()
+	due, err := db.Query().Webhooks().ClaimDue(ctx, batchSize, lease)
+	if err != nil {
+		logger.Zap.Error("cannot claim webhook deliveries", zap.Error(err))
+		return 0
+	}
+	var wg sync.WaitGroup
+	for _, d := range due {
+		wg.Add(1)
+		go func(d dbwebhooks.Due) {
+			defer wg.Done()
+			deliver(ctx, d)
+		}(d)
+	}
+	wg.Wait()
+	return len(due)
+}
+
+func deliver(ctx context.Context, d dbwebhooks.Due) {
+	responseStatus, err := send(ctx, d)
+	if err == nil {
+		err = db.Query().Webhooks().MarkDelivered(ctx, d.Id, responseStatus)
+	} else {
+		var status *int
+		if responseStatus != 0 {
+			status = &responseStatus
+		}
+		logger.Zap.Info("webhook delivery attempt failed", zap.Int("id", int(d.Id)), zap.Error(err))
+		err = db.Query().Webhooks().MarkAttemptFailed(ctx, d.Id, status, publicError(err), retryDelay(d.Attempts+1))
+	}
+	if err != nil {
+		logger.Zap.Error("cannot save webhook delivery result", zap.Int("id", int(d.Id)), zap.Error(err))
+	}
+}
+
+// send returns status of received response, or 0 if there is no response
+func send(ctx context.Context, d dbwebhooks.Due) (int, error) {
+	a, err := db.Query().Activity().Get(ctx, d.ActivityId)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get activity: %v", err)
+	}
+	body, err := json.Marshal(Payload{Event: d.Event, Timestamp: time.Now().Unix(), Activity: a})
+	if err != nil {
+		return 0, fmt.Errorf("cannot marshal payload: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Gorello-Webhook")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(int(d.Id)))
+	req.Header.Set(SignatureHeader, Sign(d.Webhook.Secret, body))
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// response is read, so connection can be reused
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, responseStatusError{status: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay returns 0 if there are no attempts left after the given one
+func retryDelay(attempt int) time.Duration {
+	if attempt >= maxAttempts {
+		return 0
+	}
+	delay := minRetryDelay << (attempt - 1)
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// Sign returns value of SignatureHeader, which is HMAC-SHA256 of body with webhook secret as key,
+// receivers should compute it the same way and compare with constant time comparison
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,164 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/access"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+// AllEvents subscribes webhook to every event of project
+const AllEvents = "*"
+
+const secretLength = 32
+
+// Webhooks are managed by project owners only, since their secrets allow to forge deliveries.
+// Changes of webhooks aren't recorded as activity, because they don't change the board.
+
+type CreateRequest struct {
+	common.Caller
+	ProjectId common.Id
+	common.WebhookSettableFields
+}
+
+type ReadRequest struct {
+	common.Caller
+	ProjectId common.Id
+	WebhookId common.Id
+}
+
+type ReadCollectionRequest struct {
+	common.Caller
+	ProjectId common.Id
+}
+
+type UpdateRequest struct {
+	common.Caller
+	ProjectId common.Id
+	WebhookId common.Id
+	common.WebhookSettableFields
+}
+
+type DeleteRequest struct {
+	common.Caller
+	ProjectId common.Id
+	WebhookId common.Id
+}
+
+type ReadDeliveriesRequest struct {
+	common.Caller
+	ProjectId common.Id
+	WebhookId common.Id
+	Limit     int `validate:"min=1,max=100"`
+}
+
+// Handle responds with the secret, so generated one can be saved by client
+func (r CreateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return common.Webhook{}, err
+	}
+	if err := checkFields(r.WebhookSettableFields); err != nil {
+		return common.Webhook{}, err
+	}
+	fields := r.WebhookSettableFields
+	if fields.Secret == "" {
+		var err error
+		if fields.Secret, err = generateSecret(); err != nil {
+			return common.Webhook{}, common.NewInternalError("cannot generate secret", err)
+		}
+	}
+	webhook, err := db.Query().Webhooks().Create(ctx, r.ProjectId, fields)
+	return webhook, common.MaybeNewInternalError("cannot create webhook", err)
+}
+
+func (r ReadRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return common.Webhook{}, err
+	}
+	webhook, err := db.Query().Webhooks().Get(ctx, r.ProjectId, r.WebhookId)
+	webhook.Secret = ""
+	return webhook, common.MaybeNewNotFoundOrInternalError("cannot get webhook", err)
+}
+
+func (r ReadCollectionRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return []common.Webhook{}, err
+	}
+	webhooks, err := db.Query().Webhooks().GetMultiple(ctx, r.ProjectId)
+	return webhooks, common.MaybeNewInternalError("cannot get webhooks", err)
+}
+
+// Handle keeps the current secret if it's omitted
+func (r UpdateRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return nil, err
+	}
+	if err := checkFields(r.WebhookSettableFields); err != nil {
+		return nil, err
+	}
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, common.NewInternalError("cannot begin transaction", err)
+	}
+	defer db.Rollback(ctx, tx)
+	before, err := db.QueryWithTX(tx).Webhooks().Get(ctx, r.ProjectId, r.WebhookId)
+	if err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot get webhook", err)
+	}
+	fields := r.WebhookSettableFields
+	if fields.Secret == "" {
+		fields.Secret = before.Secret
+	}
+	if err := db.QueryWithTX(tx).Webhooks().Update(ctx, r.ProjectId, r.WebhookId, fields); err != nil {
+		return nil, common.NewNotFoundOrInternalError("cannot update webhook", err)
+	}
+	if err := db.Commit(ctx, tx); err != nil {
+		return nil, common.NewInternalError("cannot commit transaction", err)
+	}
+	return nil, nil
+}
+
+func (r DeleteRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return nil, err
+	}
+	err := db.Query().Webhooks().Delete(ctx, r.ProjectId, r.WebhookId)
+	return nil, common.MaybeNewNotFoundOrInternalError("cannot delete webhook", err)
+}
+
+func (r ReadDeliveriesRequest) Handle(ctx context.Context) (interface{}, error) {
+	if err := access.CheckProject(ctx, r.UserId, r.ProjectId, common.RoleOwner); err != nil {
+		return []common.WebhookDelivery{}, err
+	}
+	if _, err := db.Query().Webhooks().Get(ctx, r.ProjectId, r.WebhookId); err != nil {
+		return []common.WebhookDelivery{}, common.NewNotFoundOrInternalError("cannot get webhook", err)
+	}
+	deliveries, err := db.Query().Webhooks().GetDeliveries(ctx, r.WebhookId, r.Limit)
+	return deliveries, common.MaybeNewInternalError("cannot get webhook deliveries", err)
+}
+
+func checkFields(fields common.WebhookSettableFields) error {
+	if u, err := url.Parse(fields.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return common.NewUnprocessableError("webhook url must be http or https url")
+	} else if isForbiddenHost(u.Hostname()) {
+		return common.NewUnprocessableError("webhook url must not point to internal network")
+	}
+	for _, event := range fields.Events {
+		if event != AllEvents && !common.IsEventType(event) {
+			return common.NewUnprocessableError("unknown event type " + event)
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, secretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,28 @@
+package wip
+
+import (
+	"context"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+)
+
+// CheckLimit returns Conflict error if adding given number of tasks to column exceeds its WIP limit,
+// unless limits are advisory in project. Column stays blocked until the end of transaction,
+// so the check holds while tasks are being added.
+func CheckLimit(ctx context.Context, tx db.TX, columnId common.Id, added int) error {
+	limit, advisory, err := db.QueryWithTX(tx).Columns().GetAndBlockWipLimit(ctx, columnId)
+	if err != nil {
+		return common.NewNotFoundOrInternalError("cannot get column WIP limit", err)
+	}
+	if limit == nil || advisory {
+		return nil
+	}
+	count, err := db.QueryWithTX(tx).Tasks().CountByColumn(ctx, columnId)
+	if err != nil {
+		return common.NewInternalError("cannot count column tasks", err)
+	}
+	if count+added > *limit {
+		return common.NewConflictError("column WIP limit is reached")
+	}
+	return nil
+}
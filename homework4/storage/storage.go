@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"go.uber.org/zap"
+)
+
+// ErrNotFound is returned by Get if there is no blob with given key
+var ErrNotFound = errors.New("blob not found")
+
+// Storage keeps blobs, e.g. attachments content, by keys generated by application,
+// ctx limits duration of backend requests
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	// Get returns blob content, which must be closed by caller
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete succeeds if there is no blob with given key
+	Delete(ctx context.Context, key string) error
+}
+
+var Blobs Storage
+
+// Init selects backend by STORAGE env variable: "local" (default) keeps blobs in STORAGE_DIR directory,
+// "s3" keeps them in S3_BUCKET of S3-compatible service at S3_ENDPOINT, e.g. MinIO,
+// with S3_ACCESS_KEY and S3_SECRET_KEY credentials and S3_REGION.
+func Init() error {
+	switch backend := os.Getenv("STORAGE"); backend {
+	case "", "local":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = "attachments"
+		}
+		local, err := NewLocal(dir)
+		if err != nil {
+			return err
+		}
+		Blobs = local
+	case "s3":
+		endpoint, err := url.Parse(os.Getenv("S3_ENDPOINT"))
+		if err != nil || endpoint.Host == "" {
+			return fmt.Errorf("invalid S3_ENDPOINT %q", os.Getenv("S3_ENDPOINT"))
+		}
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		Blobs = NewS3(endpoint, os.Getenv("S3_BUCKET"), region, os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+	default:
+		return fmt.Errorf("unknown STORAGE %q", backend)
+	}
+	return nil
+}
+
+// deleteTimeout limits cleanup in DeleteAll, which must not be canceled along with request
+const deleteTimeout = time.Minute
+
+// DeleteAll is used after deletion of blobs metadata is committed, blobs which cannot be deleted
+// are only logged, since metadata cannot be restored. Blobs are deleted even if ctx is done,
+// ctx is used only for logging
+func DeleteAll(ctx context.Context, keys []string) {
+	deleteCtx, cancel := context.WithTimeout(context.Background(), deleteTimeout)
+	defer cancel()
+	for _, key := range keys {
+		if err := Blobs.Delete(deleteCtx, key); err != nil {
+			logger.Ctx(ctx).Error("cannot delete blob", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
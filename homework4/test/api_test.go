@@ -1,18 +1,28 @@
 package test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/api"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/logger"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/checklists"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/columns"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/events"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/projects"
 	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/tasks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/webhooks"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/storage"
 	"github.com/stretchr/testify/assert"
 	"io"
 	"io/ioutil"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -20,11 +30,15 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 var client *http.Client
 var URL string
 
+// token is used by all requests unless another one is specified explicitly
+var token string
+
 const nonExistentId = 9999
 
 func TestMain(m *testing.M) {
@@ -34,26 +48,54 @@ func TestMain(m *testing.M) {
 	if err := db.Init(); err != nil {
 		log.Fatalf("can't initialize db: %v", err)
 	}
+	if os.Getenv("STORAGE") == "" && os.Getenv("STORAGE_DIR") == "" {
+		dir, err := ioutil.TempDir("", "attachments")
+		if err != nil {
+			log.Fatalf("can't create storage dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		os.Setenv("STORAGE_DIR", dir)
+	}
+	if err := storage.Init(); err != nil {
+		log.Fatalf("can't initialize storage: %v", err)
+	}
+	// webhook receivers of tests listen on loopback
+	os.Setenv("WEBHOOKS_ALLOW_PRIVATE_NETWORKS", "true")
+	if err := webhooks.Init(); err != nil {
+		log.Fatalf("can't initialize webhooks: %v", err)
+	}
+	go events.Run(context.Background())
+	go webhooks.Run(context.Background())
 	srv := httptest.NewServer(api.NewRouter())
 	defer srv.Close()
 	client = srv.Client()
 	URL = srv.URL
+	token = createUserAndToken(owner)
 	os.Exit(m.Run())
 }
 
+const password = "password"
+
+var owner = common.User{Id: 1, Name: "owner"}
+
+var user2 = common.User{Id: 2, Name: "user2"}
+
 var project1 = common.ProjectExpanded{
 	Project: common.Project{Id: 1, ProjectSettableFields: common.ProjectSettableFields{Name: "c", Description: "desc"}},
 	Columns: []common.ColumnExpanded{},
+	Labels:  []common.Label{},
 }
 
 var project2 = common.ProjectExpanded{
 	Project: common.Project{Id: 2, ProjectSettableFields: common.ProjectSettableFields{Name: "a", Description: "desc"}},
 	Columns: []common.ColumnExpanded{},
+	Labels:  []common.Label{},
 }
 
 var project3 = common.ProjectExpanded{
 	Project: common.Project{Id: 3, ProjectSettableFields: common.ProjectSettableFields{Name: "b", Description: "desc"}},
 	Columns: []common.ColumnExpanded{},
+	Labels:  []common.Label{},
 }
 
 var column1P1Def = common.ColumnExpanded{
@@ -87,6 +129,7 @@ var task1 = common.TaskExpanded{
 		ColumnId:           column4P3.Id,
 		Id:                 1,
 		TaskSettableFields: common.TaskSettableFields{Name: "a", Description: "desc"},
+		Labels:             []common.Label{},
 	},
 	Comments: []common.Comment{},
 }
@@ -97,6 +140,7 @@ var task2 = common.TaskExpanded{
 		ColumnId:           column5P3.Id,
 		Id:                 2,
 		TaskSettableFields: common.TaskSettableFields{Name: "b", Description: "desc"},
+		Labels:             []common.Label{},
 	},
 	Comments: []common.Comment{},
 }
@@ -107,10 +151,15 @@ var task3 = common.TaskExpanded{
 		ColumnId:           column5P3.Id,
 		Id:                 3,
 		TaskSettableFields: common.TaskSettableFields{Name: "c", Description: "desc"},
+		Labels:             []common.Label{},
 	},
 	Comments: []common.Comment{},
 }
 
+var label1P3 = common.Label{Id: 1, LabelSettableFields: common.LabelSettableFields{Name: "bug", Color: "#ff0000"}}
+
+var label2P1 = common.Label{Id: 2, LabelSettableFields: common.LabelSettableFields{Name: "bug", Color: "#00ff00"}}
+
 var comment1T3 = common.Comment{Id: 1, CommentSettableFields: common.CommentSettableFields{Text: "text"}}
 
 var comment2T3 = common.Comment{Id: 2, CommentSettableFields: common.CommentSettableFields{Text: "text"}}
@@ -127,14 +176,23 @@ func Test_Complex(t *testing.T) {
 	assertPost201(t, tasksPath(project3.Id, column5P3.Id), task2.TaskSettableFields, task2.Task)
 	assertPost201(t, tasksPath(project3.Id, column5P3.Id), task3.TaskSettableFields, task3.Task)
 
-	assertPost201(t, commentsPath(task3.Id), comment1T3.CommentSettableFields, comment1T3)
-	assertPost201(t, commentsPath(task3.Id), comment2T3.CommentSettableFields, comment2T3)
+	comment1T3 = assertPostComment(t, task3.Id, comment1T3)
+	comment2T3 = assertPostComment(t, task3.Id, comment2T3)
 
 	runSubtestsCreate(t)
 	runSubtestsGet(t)
+	runSubtestsAccess(t)
 	runSubtestsUpdate(t)
+	runSubtestsLabels(t)
+	runSubtestsSearch(t)
 	runSubtestsUpdateColumnPosition(t)
 	runSubtestsUpdateTaskPosition(t)
+	runSubtestsActivity(t)
+	runSubtestsWipLimits(t)
+	runSubtestsWebhooks(t)
+	runSubtestsChecklists(t)
+	runSubtestsAttachments(t)
+	runSubtestsArchive(t)
 	runSubtestsDelete(t)
 }
 
@@ -147,7 +205,35 @@ func runSubtestsCreate(t *testing.T) {
 func runSubtestsGet(t *testing.T) {
 	t.Run("get projects ordered by name", func(t *testing.T) {
 		projects := []common.Project{project2.Project, project3.Project, project1.Project}
-		assertGet200(t, projectsPath(), projects)
+		assertGet200(t, projectsPath(), common.ProjectsPage{Projects: projects})
+	})
+	t.Run("get projects page by page", func(t *testing.T) {
+		page := getProjectsPage(t, projectsPath()+"?limit=2&sort=id")
+		assert.Equal(t, []common.Project{project1.Project, project2.Project}, page.Projects)
+		if !assert.NotEmpty(t, page.NextCursor) {
+			t.FailNow()
+		}
+		page = getProjectsPage(t, projectsPath()+"?limit=2&sort=id&cursor="+page.NextCursor)
+		assert.Equal(t, []common.Project{project3.Project}, page.Projects)
+		assert.Empty(t, page.NextCursor)
+	})
+	t.Run("get projects filtered by name", func(t *testing.T) {
+		projects := []common.Project{project3.Project}
+		assertGet200(t, projectsPath()+"?name=B", common.ProjectsPage{Projects: projects})
+	})
+	t.Run("cannot use cursor with another sort key", func(t *testing.T) {
+		page := getProjectsPage(t, projectsPath()+"?limit=1&sort=id")
+		assertGet400(t, projectsPath()+"?limit=1&sort=name&cursor="+page.NextCursor)
+	})
+	t.Run("cannot use cursor with invalid key", func(t *testing.T) {
+		for _, sort := range []string{"id", "created", "name"} {
+			cursor := common.EncodeCursor(common.Cursor{Sort: sort, Key: "garbage\x00", Id: project1.Id})
+			assertGet400(t, projectsPath()+"?limit=1&sort="+sort+"&cursor="+cursor)
+		}
+	})
+	t.Run("cannot get projects with invalid limit", func(t *testing.T) {
+		assertGet422(t, projectsPath()+"?limit=1000")
+		assertGet422(t, projectsPath()+"?limit=abc")
 	})
 	t.Run("get expanded project", func(t *testing.T) {
 		c1 := column3P3Def
@@ -180,6 +266,65 @@ func runSubtestsGet(t *testing.T) {
 	})
 }
 
+func runSubtestsAccess(t *testing.T) {
+	user2Token := createUserAndToken(user2)
+	t.Run("cannot get projects without token", func(t *testing.T) {
+		resp := sendRequestWithToken(t, "", "GET", projectsPath(), nil)
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusUnauthorized)
+	})
+	t.Run("cannot create token with wrong password", func(t *testing.T) {
+		resp := sendPostRequest(t, tokensPath(), common.Credentials{Name: owner.Name, Password: "wrong password"})
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusUnauthorized)
+	})
+	t.Run("get user", func(t *testing.T) {
+		assertGet200(t, userPath(user2.Id), user2)
+	})
+	t.Run("get only projects where user is member", func(t *testing.T) {
+		resp := sendRequestWithToken(t, user2Token, "GET", projectsPath(), nil)
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		assertEqualBody(t, resp, common.ProjectsPage{Projects: []common.Project{}})
+	})
+	t.Run("cannot get project without membership", func(t *testing.T) {
+		resp := sendRequestWithToken(t, user2Token, "GET", projectPath(project3.Id), nil)
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusForbidden)
+	})
+	t.Run("viewer can read project but cannot update it", func(t *testing.T) {
+		body := common.MemberSettableFields{Role: common.RoleViewer}
+		assertPut204(t, memberPath(project3.Id, user2.Id), body)
+		members := []common.Member{
+			{User: owner, MemberSettableFields: common.MemberSettableFields{Role: common.RoleOwner}},
+			{User: user2, MemberSettableFields: common.MemberSettableFields{Role: common.RoleViewer}},
+		}
+		assertGet200(t, membersPath(project3.Id), members)
+		resp := sendRequestWithToken(t, user2Token, "GET", projectPath(project3.Id), nil)
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		resp.Body.Close()
+		resp = sendRequestWithToken(t, user2Token, "PUT", projectPath(project3.Id), project3.ProjectSettableFields)
+		assertEqualStatusCode(t, resp, http.StatusForbidden)
+		resp.Body.Close()
+		resp = sendRequestWithToken(t, user2Token, "GET", taskPath(task1.Id), nil)
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		resp.Body.Close()
+		resp = sendRequestWithToken(t, user2Token, "DELETE", taskPath(task1.Id), nil)
+		assertEqualStatusCode(t, resp, http.StatusForbidden)
+		resp.Body.Close()
+	})
+	t.Run("cannot remove last owner", func(t *testing.T) {
+		assertDelete409(t, memberPath(project3.Id, owner.Id))
+	})
+	t.Run("remove member", func(t *testing.T) {
+		resp := sendDeleteRequest(t, memberPath(project3.Id, user2.Id))
+		assertEqualStatusCode(t, resp, http.StatusNoContent)
+		resp = sendRequestWithToken(t, user2Token, "GET", projectPath(project3.Id), nil)
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusForbidden)
+	})
+}
+
 func runSubtestsUpdate(t *testing.T) {
 	t.Run("update project", func(t *testing.T) {
 		project3.Name = "c1"
@@ -203,13 +348,212 @@ func runSubtestsUpdate(t *testing.T) {
 		assertPut204(t, taskPath(task3.Id), task3.TaskSettableFields)
 		assertGet200(t, taskPath(task3.Id), task3.Task)
 	})
+	t.Run("update task planning fields", func(t *testing.T) {
+		assigneeId, dueDate, priority, estimate := owner.Id, "2020-12-31", "high", 8
+		task3.AssigneeId = &assigneeId
+		task3.DueDate = &dueDate
+		task3.Priority = &priority
+		task3.Estimate = &estimate
+		assertPut204(t, taskPath(task3.Id), task3.TaskSettableFields)
+		assertGet200(t, taskPath(task3.Id), task3.Task)
+	})
+	t.Run("cannot assign task to user who isn't project member", func(t *testing.T) {
+		task := task3
+		assigneeId := user2.Id
+		task.AssigneeId = &assigneeId
+		assertPut409(t, taskPath(task.Id), task.TaskSettableFields)
+	})
+	t.Run("cannot set invalid task planning fields", func(t *testing.T) {
+		task := task3
+		priority, dueDate := "very high", "31.12.2020"
+		task.Priority = &priority
+		assertPut422(t, taskPath(task.Id), task.TaskSettableFields)
+		task = task3
+		task.DueDate = &dueDate
+		assertPut422(t, taskPath(task.Id), task.TaskSettableFields)
+	})
+	t.Run("patch project", func(t *testing.T) {
+		project3.Description = "desc2"
+		assertPatch(t, projectPath(project3.Id), map[string]interface{}{"description": "desc2"}, http.StatusNoContent)
+		assertGet200(t, projectPath(project3.Id), project3.Project)
+	})
+	t.Run("patch task", func(t *testing.T) {
+		task3.Description = "desc2"
+		task3.Priority = nil
+		patch := map[string]interface{}{"description": task3.Description, "priority": nil}
+		assertPatch(t, taskPath(task3.Id), patch, http.StatusNoContent)
+		assertGet200(t, taskPath(task3.Id), task3.Task)
+	})
+	t.Run("cannot patch task with invalid fields", func(t *testing.T) {
+		assertPatch(t, taskPath(task3.Id), map[string]interface{}{"name": ""}, http.StatusUnprocessableEntity)
+		assertPatch(t, taskPath(task3.Id), map[string]interface{}{"name": nil}, http.StatusBadRequest)
+		assertPatch(t, taskPath(task3.Id), map[string]interface{}{"unknown": 1}, http.StatusBadRequest)
+		assertPatch(t, taskPath(task3.Id), map[string]interface{}{"assignee_id": user2.Id}, http.StatusConflict)
+		assertGet200(t, taskPath(task3.Id), task3.Task)
+	})
+	t.Run("conditional get and update of task", func(t *testing.T) {
+		resp := sendGetRequest(t, taskPath(task2.Id))
+		resp.Body.Close()
+		etag := resp.Header.Get("ETag")
+		resp = sendRequestWithHeader(t, "GET", taskPath(task2.Id), "If-None-Match", etag, nil)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusNotModified)
+		resp = sendRequestWithHeader(t, "PUT", taskPath(task2.Id), "If-Match", etag, task2.TaskSettableFields)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusNoContent)
+		resp = sendRequestWithHeader(t, "GET", taskPath(task2.Id), "If-None-Match", etag, nil)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+	})
+	t.Run("cannot update or delete task with stale If-Match", func(t *testing.T) {
+		resp := sendRequestWithHeader(t, "PUT", taskPath(task2.Id), "If-Match", `"1"`, task2.TaskSettableFields)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusPreconditionFailed)
+		resp = sendRequestWithHeader(t, "DELETE", taskPath(task2.Id), "If-Match", `"1"`, nil)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusPreconditionFailed)
+	})
+	t.Run("get expanded project with weak ETag", func(t *testing.T) {
+		resp := sendGetRequest(t, projectPath(project3.Id)+"?expanded")
+		resp.Body.Close()
+		etag := resp.Header.Get("ETag")
+		assert.True(t, strings.HasPrefix(etag, `W/"`))
+		resp = sendRequestWithHeader(t, "GET", projectPath(project3.Id)+"?expanded", "If-None-Match", etag, nil)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusNotModified)
+	})
 	t.Run("update comment", func(t *testing.T) {
+		before := comment1T3
 		comment1T3.Text = "text1"
+		assertPut204(t, commentPath(task3.Id, comment1T3.Id), comment1T3.CommentSettableFields)
+		got := getComment(t, task3.Id, comment1T3.Id)
+		assert.True(t, got.UpdatedAt.After(before.UpdatedAt))
+		comment1T3.UpdatedAt = got.UpdatedAt
+		assert.Equal(t, comment1T3, got)
+		page := getActivityPage(t, taskActivityPath(task3.Id)+"?limit=1")
+		if assert.Len(t, page.Activity, 1) {
+			after := common.Comment{}
+			if err := json.Unmarshal(page.Activity[0].Diff.After, &after); err != nil {
+				t.Fatalf("error while decoding activity diff: %v", err)
+			}
+			assert.True(t, after.UpdatedAt.Equal(got.UpdatedAt), "activity diff has stale updated_at")
+		}
+		revisions := []common.CommentRevision{}
+		resp := sendGetRequest(t, commentPath(task3.Id, comment1T3.Id)+"/revisions")
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+			t.Fatalf("error while decoding response body: %v", err)
+		}
+		if assert.Len(t, revisions, 1) {
+			assert.Equal(t, before.Text, revisions[0].Text)
+			assert.True(t, revisions[0].CreatedAt.Equal(before.UpdatedAt))
+			assert.True(t, revisions[0].ReplacedAt.Equal(got.UpdatedAt))
+		}
+	})
+	t.Run("update comment without text change", func(t *testing.T) {
 		assertPut204(t, commentPath(task3.Id, comment1T3.Id), comment1T3.CommentSettableFields)
 		assertGet200(t, commentPath(task3.Id, comment1T3.Id), comment1T3)
+		resp := sendGetRequest(t, commentPath(task3.Id, comment1T3.Id)+"/revisions")
+		defer resp.Body.Close()
+		revisions := []common.CommentRevision{}
+		if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+			t.Fatalf("error while decoding response body: %v", err)
+		}
+		assert.Len(t, revisions, 1)
 	})
 }
 
+func runSubtestsLabels(t *testing.T) {
+	t.Run("create labels", func(t *testing.T) {
+		assertPost201(t, labelsPath(project3.Id), label1P3.LabelSettableFields, label1P3)
+		assertPost201(t, labelsPath(project1.Id), label2P1.LabelSettableFields, label2P1)
+	})
+	t.Run("cannot create label with duplicate name", func(t *testing.T) {
+		assertPost409(t, labelsPath(project3.Id), label1P3.LabelSettableFields)
+	})
+	t.Run("cannot create label with invalid color", func(t *testing.T) {
+		body := common.LabelSettableFields{Name: "feature", Color: "red"}
+		assertPost422(t, labelsPath(project3.Id), body)
+	})
+	t.Run("attach label to task", func(t *testing.T) {
+		assertPut204(t, taskLabelPath(task2.Id, label1P3.Id), nil)
+		task := task2
+		task.Labels = []common.Label{label1P3}
+		assertGet200(t, taskPath(task.Id), task.Task)
+		assertGet200(t, taskLabelsPath(task.Id), []common.Label{label1P3})
+		c1 := column3P3Def
+		c2 := column4P3
+		c2.Tasks = []common.Task{task1.Task}
+		c3 := column5P3
+		c3.Tasks = []common.Task{task.Task, task3.Task}
+		p := project3
+		p.Columns = []common.ColumnExpanded{c1, c2, c3}
+		p.Labels = []common.Label{label1P3}
+		assertGet200(t, projectPath(p.Id)+"?expanded", p)
+	})
+	t.Run("cannot attach label from another project", func(t *testing.T) {
+		assertPut409(t, taskLabelPath(task2.Id, label2P1.Id), nil)
+	})
+	t.Run("delete label and detach it from tasks", func(t *testing.T) {
+		assertDelete204(t, labelPath(project3.Id, label1P3.Id))
+		assertGet200(t, taskLabelsPath(task2.Id), []common.Label{})
+	})
+}
+
+func runSubtestsSearch(t *testing.T) {
+	t.Run("search comment", func(t *testing.T) {
+		commentId := comment1T3.Id
+		hits := []common.SearchHit{{
+			Type:        "comment",
+			ProjectId:   project3.Id,
+			ProjectName: project3.Name,
+			ColumnId:    column5P3.Id,
+			ColumnName:  column5P3.Name,
+			TaskId:      task3.Id,
+			CommentId:   &commentId,
+			Snippet:     "<b>" + comment1T3.Text + "</b>",
+		}}
+		assertEqualSearchHits(t, searchPath()+"?q="+comment1T3.Text, hits)
+	})
+	t.Run("search task within project", func(t *testing.T) {
+		hits := []common.SearchHit{{
+			Type:        "task",
+			ProjectId:   project3.Id,
+			ProjectName: project3.Name,
+			ColumnId:    column5P3.Id,
+			ColumnName:  column5P3.Name,
+			TaskId:      task3.Id,
+			Snippet:     task3.Name + " <b>" + task3.Description + "</b>",
+		}}
+		path := searchPath() + "?q=" + task3.Description + "&project_id=" + idToStr(project3.Id)
+		assertEqualSearchHits(t, path, hits)
+		path = searchPath() + "?q=" + task3.Description + "&project_id=" + idToStr(project1.Id)
+		assertEqualSearchHits(t, path, []common.SearchHit{})
+	})
+	t.Run("cannot search without query", func(t *testing.T) {
+		assertGet422(t, searchPath())
+	})
+}
+
+// rank is implementation specific, so it's not compared
+func assertEqualSearchHits(t *testing.T, path string, wantHits []common.SearchHit) {
+	resp := sendGetRequest(t, path)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	gotHits := []common.SearchHit{}
+	if err := json.NewDecoder(resp.Body).Decode(&gotHits); err != nil {
+		t.Fatalf("error while decoding search hits: %v", err)
+	}
+	for i := range gotHits {
+		gotHits[i].Rank = 0
+	}
+	if !assert.Equal(t, wantHits, gotHits, "search hits mismatch") {
+		t.FailNow()
+	}
+}
+
 func runSubtestsUpdateColumnPosition(t *testing.T) {
 	t.Run("cannot update position of non existent column", func(t *testing.T) {
 		body := columns.UpdatePositionRequestBody{AfterColumnId: column3P3Def.Id}
@@ -282,6 +626,597 @@ func runSubtestsUpdateTaskPosition(t *testing.T) {
 		assertPut204(t, taskPositionPath(task1.Id), body)
 		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
 	})
+	t.Run("batch is rolled back on failure", func(t *testing.T) {
+		ops := []tasks.BatchOperation{
+			{Op: tasks.OpMove, TaskId: task3.Id, NewColumnId: column4P3.Id},
+			{Op: tasks.OpMove, TaskId: task2.Id, NewColumnId: nonExistentId},
+		}
+		resp := sendPostRequest(t, tasksBatchPath(), tasks.BatchRequest{Operations: ops})
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusConflict)
+		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
+	})
+	t.Run("batch moves tasks contiguously", func(t *testing.T) {
+		ops := []tasks.BatchOperation{
+			{Op: tasks.OpMove, TaskId: task2.Id, NewColumnId: column4P3.Id},
+			{Op: tasks.OpMove, TaskId: task1.Id, NewColumnId: column4P3.Id},
+		}
+		results := postBatch(t, ops)
+		if assert.Len(t, results, 2) {
+			assert.Equal(t, column4P3.Id, results[1].Task.ColumnId)
+		}
+		task1.ColumnId, task2.ColumnId = column4P3.Id, column4P3.Id
+		column4P3.Tasks = []common.Task{task2.Task, task1.Task}
+		column5P3.Tasks = []common.Task{}
+		project3.Columns = []common.ColumnExpanded{column4P3, column3P3Def, column5P3}
+		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
+
+		ops[0].NewColumnId, ops[1].NewColumnId = column5P3.Id, column5P3.Id
+		postBatch(t, ops)
+		task1.ColumnId, task2.ColumnId = column5P3.Id, column5P3.Id
+		column4P3.Tasks = []common.Task{}
+		column5P3.Tasks = []common.Task{task2.Task, task1.Task}
+		project3.Columns = []common.ColumnExpanded{column4P3, column3P3Def, column5P3}
+		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
+	})
+}
+
+func postBatch(t *testing.T, ops []tasks.BatchOperation) []tasks.BatchResult {
+	resp := sendPostRequest(t, tasksBatchPath(), tasks.BatchRequest{Operations: ops})
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	results := []tasks.BatchResult{}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatalf("error while decoding batch results: %v", err)
+	}
+	return results
+}
+
+func runSubtestsActivity(t *testing.T) {
+	t.Run("get task activity page by page", func(t *testing.T) {
+		page := getActivityPage(t, taskActivityPath(task1.Id)+"?limit=1")
+		if !assert.Len(t, page.Activity, 1) || !assert.NotEmpty(t, page.NextCursor) {
+			t.FailNow()
+		}
+		move := page.Activity[0]
+		assert.Equal(t, owner.Id, move.ActorId)
+		assert.Equal(t, common.ResourceTask, move.ResourceType)
+		assert.Equal(t, task1.Id, move.ResourceId)
+		assert.Equal(t, common.ActionMove, move.Action)
+		assert.JSONEq(t, `{"column_id":`+idToStr(column4P3.Id)+`}`, string(move.Diff.Before))
+		want := `{"column_id":` + idToStr(column5P3.Id) + `,"after_task_id":` + idToStr(task2.Id) + `}`
+		assert.JSONEq(t, want, string(move.Diff.After))
+		var last common.Activity
+		for page.NextCursor != "" {
+			page = getActivityPage(t, taskActivityPath(task1.Id)+"?limit=1&cursor="+page.NextCursor)
+			last = page.Activity[len(page.Activity)-1]
+		}
+		assert.Equal(t, common.ActionCreate, last.Action)
+		assert.Nil(t, last.Diff.Before)
+	})
+	t.Run("project activity includes task changes", func(t *testing.T) {
+		page := getActivityPage(t, projectActivityPath(project3.Id)+"?limit=1")
+		assert.Equal(t, common.ActionMove, page.Activity[0].Action)
+		assert.Equal(t, task1.Id, page.Activity[0].ResourceId)
+	})
+	t.Run("cannot get activity with invalid cursor", func(t *testing.T) {
+		assertGet400(t, taskActivityPath(task1.Id)+"?cursor=invalid")
+	})
+	t.Run("stream project events", func(t *testing.T) {
+		stream := openEventStream(t, projectEventsPath(project3.Id), 0)
+		assertPut204(t, commentPath(task3.Id, comment2T3.Id), comment2T3.CommentSettableFields)
+		event := readEvent(t, stream)
+		assert.Equal(t, "comment.update", event.name)
+		assert.Equal(t, comment2T3.Id, event.activity.ResourceId)
+		assert.NotZero(t, event.id)
+
+		replayed := openEventStream(t, projectEventsPath(project3.Id), event.id-1)
+		assert.Equal(t, event, readEvent(t, replayed))
+	})
+	t.Run("cannot stream events of non existent project", func(t *testing.T) {
+		assertGet404(t, projectEventsPath(nonExistentId))
+	})
+}
+
+type sseEvent struct {
+	id       common.Id
+	name     string
+	activity common.Activity
+}
+
+// openEventStream returns reader of event stream, stream is closed after timeout or at the end of test
+func openEventStream(t *testing.T, path string, lastEventId common.Id) *bufio.Reader {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, "GET", URL+api.BasePath+path, nil)
+	if err != nil {
+		t.Fatalf("new request failed: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if lastEventId != 0 {
+		req.Header.Set("Last-Event-ID", idToStr(lastEventId))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("error while sending request: %v", err)
+	}
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent skips heartbeats and returns next event from stream
+func readEvent(t *testing.T, stream *bufio.Reader) sseEvent {
+	event := sseEvent{}
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("error while reading event stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.name != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			id, _ := strconv.Atoi(strings.TrimPrefix(line, "id: "))
+			event.id = common.Id(id)
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.activity); err != nil {
+				t.Fatalf("error while decoding event data: %v", err)
+			}
+		}
+	}
+}
+
+func runSubtestsWipLimits(t *testing.T) {
+	limit := 1
+	limited := common.Column{}
+	postResource(t, columnsPath(project2.Id), common.ColumnSettableFields{Name: "limited", WipLimit: &limit}, &limited)
+	assert.Equal(t, &limit, limited.WipLimit)
+	fields := common.TaskSettableFields{Name: "task"}
+	task := common.Task{}
+	postResource(t, tasksPath(project2.Id, limited.Id), fields, &task)
+	t.Run("cannot create task in full column", func(t *testing.T) {
+		assertPost409(t, tasksPath(project2.Id, limited.Id), fields)
+	})
+	t.Run("cannot move task to full column", func(t *testing.T) {
+		other := common.Task{}
+		postResource(t, tasksPath(project2.Id, column2P2Def.Id), fields, &other)
+		body := tasks.UpdatePositionRequestBody{NewColumnId: limited.Id}
+		assertPut409(t, taskPositionPath(other.Id), body)
+		assertDelete409(t, columnPath(project2.Id, column2P2Def.Id))
+		assertDelete204(t, taskPath(other.Id))
+	})
+	t.Run("advisory WIP limits may be exceeded", func(t *testing.T) {
+		assertPatch(t, projectPath(project2.Id), map[string]interface{}{"wip_limits_advisory": true}, http.StatusNoContent)
+		assertPost201(t, tasksPath(project2.Id, limited.Id), fields, common.Task{
+			ProjectId:          project2.Id,
+			ColumnId:           limited.Id,
+			Id:                 task.Id + 2,
+			TaskSettableFields: fields,
+			Labels:             []common.Label{},
+		})
+		assertPatch(t, projectPath(project2.Id), map[string]interface{}{"wip_limits_advisory": false}, http.StatusNoContent)
+	})
+	t.Run("remove WIP limit", func(t *testing.T) {
+		assertPatch(t, columnPath(project2.Id, limited.Id), map[string]interface{}{"wip_limit": nil}, http.StatusNoContent)
+		assertPost201(t, tasksPath(project2.Id, limited.Id), fields, common.Task{
+			ProjectId:          project2.Id,
+			ColumnId:           limited.Id,
+			Id:                 task.Id + 3,
+			TaskSettableFields: fields,
+			Labels:             []common.Label{},
+		})
+	})
+	t.Run("cannot restore task into full column", func(t *testing.T) {
+		limit := 3
+		assertPatch(t, columnPath(project2.Id, limited.Id), map[string]interface{}{"wip_limit": limit}, http.StatusNoContent)
+		assertDelete204(t, taskPath(task.Id))
+		other := common.Task{}
+		postResource(t, tasksPath(project2.Id, limited.Id), fields, &other)
+		assertPost(t, restorePath(taskPath(task.Id)), http.StatusConflict)
+		assertDelete204(t, taskPath(other.Id))
+		assertPost(t, restorePath(taskPath(task.Id)), http.StatusNoContent)
+	})
+	assertDelete204(t, columnPath(project2.Id, limited.Id))
+}
+
+type receivedDelivery struct {
+	header http.Header
+	body   []byte
+}
+
+func runSubtestsWebhooks(t *testing.T) {
+	received := make(chan receivedDelivery, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- receivedDelivery{header: r.Header, body: body}
+	}))
+	defer receiver.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	path := projectPath(project3.Id) + "/webhooks"
+	webhook := common.Webhook{}
+	fields := common.WebhookSettableFields{
+		URL:    receiver.URL,
+		Events: []string{"checklist.create"},
+		Secret: "0123456789abcdef",
+	}
+	postResource(t, path, fields, &webhook)
+	failingWebhook := common.Webhook{}
+	postResource(t, path, common.WebhookSettableFields{URL: failing.URL, Events: []string{webhooks.AllEvents}},
+		&failingWebhook)
+	t.Run("get webhook without secret", func(t *testing.T) {
+		assert.Len(t, failingWebhook.Secret, 64)
+		want := webhook
+		want.Secret = ""
+		assertGet200(t, path+"/"+idToStr(webhook.Id), want)
+	})
+	t.Run("cannot subscribe to unknown event", func(t *testing.T) {
+		assertPost422(t, path, common.WebhookSettableFields{URL: receiver.URL, Events: []string{"task.fly"}})
+	})
+	checklist := common.Checklist{}
+	t.Run("receive signed delivery", func(t *testing.T) {
+		postResource(t, taskPath(task1.Id)+"/checklists", common.ChecklistSettableFields{Name: "hooked"}, &checklist)
+		var d receivedDelivery
+		select {
+		case d = <-received:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("delivery isn't received")
+		}
+		assert.Equal(t, webhooks.Sign(fields.Secret, d.body), d.header.Get(webhooks.SignatureHeader))
+		assert.Equal(t, "checklist.create", d.header.Get(webhooks.EventHeader))
+		payload := webhooks.Payload{}
+		if err := json.Unmarshal(d.body, &payload); err != nil {
+			t.Fatalf("cannot unmarshal payload: %v", err)
+		}
+		assert.Equal(t, "checklist.create", payload.Event)
+		assert.InDelta(t, time.Now().Unix(), payload.Timestamp, 60)
+		assert.Equal(t, checklist.Id, payload.Activity.ResourceId)
+		deliveries := waitDeliveries(t, path+"/"+idToStr(webhook.Id)+"/deliveries")
+		assert.Equal(t, common.DeliveryDelivered, deliveries[0].Status)
+		assert.Equal(t, http.StatusOK, *deliveries[0].ResponseStatus)
+	})
+	t.Run("failed delivery is retried later", func(t *testing.T) {
+		deliveries := waitDeliveries(t, path+"/"+idToStr(failingWebhook.Id)+"/deliveries")
+		assert.Equal(t, common.DeliveryPending, deliveries[0].Status)
+		assert.Equal(t, http.StatusInternalServerError, *deliveries[0].ResponseStatus)
+		assert.True(t, deliveries[0].NextAttemptAt.After(*deliveries[0].LastAttemptAt))
+	})
+	assertDelete204(t, checklistPath(checklist.Id))
+	assertDelete204(t, path+"/"+idToStr(webhook.Id))
+	assertDelete204(t, path+"/"+idToStr(failingWebhook.Id))
+}
+
+// waitDeliveries waits until the first attempt of the latest delivery is made
+func waitDeliveries(t *testing.T, path string) []common.WebhookDelivery {
+	for i := 0; i < 100; i++ {
+		var deliveries []common.WebhookDelivery
+		resp := sendGetRequest(t, path)
+		err := json.NewDecoder(resp.Body).Decode(&deliveries)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("error while decoding response body: %v", err)
+		}
+		if len(deliveries) > 0 && deliveries[0].Attempts > 0 {
+			return deliveries
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("delivery attempt isn't made")
+	return nil
+}
+
+func runSubtestsChecklists(t *testing.T) {
+	checklist := common.Checklist{}
+	postResource(t, taskPath(task1.Id)+"/checklists", common.ChecklistSettableFields{Name: "todo"}, &checklist)
+	itemsPath := checklistPath(checklist.Id) + "/items"
+	items := make([]common.ChecklistItem, 3)
+	for i, text := range []string{"a", "b", "c"} {
+		postResource(t, itemsPath, common.ChecklistItemSettableFields{Text: text}, &items[i])
+	}
+	t.Run("complete checklist item", func(t *testing.T) {
+		path := itemsPath + "/" + idToStr(items[1].Id)
+		assertPatch(t, path, map[string]interface{}{"done": true}, http.StatusNoContent)
+		items[1].Done = true
+		assertGet200(t, path, items[1])
+		task := task1.Task
+		task.Checklists = common.ChecklistsProgress{Done: 1, Total: 3}
+		assertGet200(t, taskPath(task1.Id), task)
+	})
+	t.Run("move checklist item", func(t *testing.T) {
+		body := checklists.UpdateItemPositionRequestBody{AfterItemId: 0}
+		assertPut204(t, itemsPath+"/"+idToStr(items[2].Id)+"/position", body)
+		checklist.Items = []common.ChecklistItem{items[2], items[0], items[1]}
+		assertGet200(t, taskPath(task1.Id)+"/checklists", []common.Checklist{checklist})
+	})
+	t.Run("cannot place checklist item after item of another checklist", func(t *testing.T) {
+		other := common.Checklist{}
+		postResource(t, taskPath(task1.Id)+"/checklists", common.ChecklistSettableFields{Name: "other"}, &other)
+		body := checklists.UpdateItemPositionRequestBody{AfterItemId: items[0].Id}
+		otherItem := common.ChecklistItem{}
+		postResource(t, checklistPath(other.Id)+"/items", common.ChecklistItemSettableFields{Text: "a"}, &otherItem)
+		assertPut409(t, checklistPath(other.Id)+"/items/"+idToStr(otherItem.Id)+"/position", body)
+		assertDelete204(t, checklistPath(other.Id))
+	})
+	t.Run("delete checklist", func(t *testing.T) {
+		assertDelete204(t, checklistPath(checklist.Id))
+		assertGet404(t, checklistPath(checklist.Id))
+		assertGet200(t, taskPath(task1.Id), task1.Task)
+	})
+}
+
+// pngHeader is enough for content type to be detected as PNG image
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func runSubtestsAttachments(t *testing.T) {
+	path := taskPath(task1.Id) + "/attachments"
+	attachment := common.Attachment{}
+	t.Run("upload attachment", func(t *testing.T) {
+		resp := uploadFile(t, path, "image.txt", pngHeader)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		if err := json.NewDecoder(resp.Body).Decode(&attachment); err != nil {
+			t.Fatalf("error while decoding response body: %v", err)
+		}
+		assert.Equal(t, "image.txt", attachment.Name)
+		assert.Equal(t, "image/png", attachment.ContentType)
+		assert.Equal(t, int64(len(pngHeader)), attachment.Size)
+		assert.Equal(t, path+"/"+idToStr(attachment.Id), resp.Header.Get("Location"))
+	})
+	t.Run("download attachment", func(t *testing.T) {
+		resp := sendGetRequest(t, path+"/"+idToStr(attachment.Id))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=image.txt`, resp.Header.Get("Content-Disposition"))
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("error while reading response body: %v", err)
+		}
+		assert.Equal(t, pngHeader, content)
+	})
+	t.Run("get attachments", func(t *testing.T) {
+		assertGet200(t, path, []common.Attachment{attachment})
+	})
+	t.Run("upload too large attachment", func(t *testing.T) {
+		resp := uploadFile(t, path, "large.bin", make([]byte, 11<<20))
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+	t.Run("upload too large attachment without content length", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		form := multipart.NewWriter(body)
+		part, err := form.CreateFormFile("file", "large.bin")
+		if err != nil {
+			t.Fatalf("error while creating form file: %v", err)
+		}
+		part.Write(make([]byte, 11<<20))
+		form.Close()
+		// reader of unknown size makes request chunked
+		req, err := http.NewRequest(http.MethodPost, URL+api.BasePath+path, ioutil.NopCloser(body))
+		if err != nil {
+			t.Fatalf("new request failed: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		resp := doRequest(t, req)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+	t.Run("delete attachment", func(t *testing.T) {
+		assertDelete204(t, path+"/"+idToStr(attachment.Id))
+		assertGet404(t, path+"/"+idToStr(attachment.Id))
+	})
+}
+
+func uploadFile(t *testing.T, path, filename string, content []byte) *http.Response {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("error while creating form file: %v", err)
+	}
+	if _, err := part.Write(content); err != nil {
+		t.Fatalf("error while writing form file: %v", err)
+	}
+	if err := form.Close(); err != nil {
+		t.Fatalf("error while closing form: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, URL+api.BasePath+path, body)
+	if err != nil {
+		t.Fatalf("new request failed: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return doRequest(t, req)
+}
+
+func runSubtestsArchive(t *testing.T) {
+	var archive common.ProjectArchive
+	t.Run("export project", func(t *testing.T) {
+		archive = exportProject(t, project3.Id)
+		assert.Equal(t, common.ArchiveVersion, archive.Version)
+		assert.Equal(t, project3.ProjectSettableFields, archive.Project.ProjectSettableFields)
+		assert.Equal(t, boardOfProject(project3), boardOfArchive(archive))
+	})
+	t.Run("get tasks report", func(t *testing.T) {
+		resp := sendGetRequest(t, projectPath(project3.Id)+"/tasks.csv")
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		records, err := csv.NewReader(resp.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("error while reading report: %v", err)
+		}
+		board := boardOfArchive(archive)
+		rows := [][]string{}
+		for _, column := range board {
+			for i, task := range column[1:] {
+				rows = append(rows, []string{column[0], strconv.Itoa(i + 1), task})
+			}
+		}
+		reported := [][]string{}
+		for _, r := range records[1:] {
+			reported = append(reported, r[1:4])
+		}
+		assert.Equal(t, "id", records[0][0])
+		assert.Equal(t, rows, reported)
+	})
+	t.Run("import project", func(t *testing.T) {
+		resp := sendPostRequest(t, projectsPath()+"/import", archive)
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusCreated)
+		result := common.ProjectImport{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("error while decoding import result: %v", err)
+		}
+		assert.Len(t, result.Columns, len(archive.Columns))
+		imported := exportProject(t, result.ProjectId)
+		assert.Equal(t, boardOfArchive(archive), boardOfArchive(imported))
+		assertDelete204(t, projectPath(result.ProjectId))
+	})
+	t.Run("cannot import archive with duplicate column names", func(t *testing.T) {
+		invalid := archive
+		invalid.Columns = append([]common.ArchivedColumn{archive.Columns[0]}, archive.Columns...)
+		assertPost422(t, projectsPath()+"/import", invalid)
+	})
+	t.Run("cannot import archive with column exceeding WIP limit", func(t *testing.T) {
+		invalid := archive
+		invalid.Columns = append([]common.ArchivedColumn{}, archive.Columns...)
+		var column *common.ArchivedColumn
+		for i := range invalid.Columns {
+			if len(invalid.Columns[i].Tasks) > 0 {
+				column = &invalid.Columns[i]
+			}
+		}
+		if column == nil {
+			t.Fatalf("archive has no tasks")
+		}
+		column.Tasks = append(append([]common.ArchivedTask{}, column.Tasks...), column.Tasks[0])
+		limit := len(column.Tasks) - 1
+		column.WipLimit = &limit
+		assertPost422(t, projectsPath()+"/import", invalid)
+		invalid.Project.WipLimitsAdvisory = true
+		resp := sendPostRequest(t, projectsPath()+"/import", invalid)
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusCreated)
+		result := common.ProjectImport{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("error while decoding import result: %v", err)
+		}
+		assertDelete204(t, projectPath(result.ProjectId))
+	})
+	t.Run("import Trello board", func(t *testing.T) {
+		resp := sendPostRequest(t, projectsPath()+"/import/trello", json.RawMessage(trelloBoard))
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusCreated)
+		result := projects.TrelloImport{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("error while decoding import result: %v", err)
+		}
+		imported := exportProject(t, result.ProjectId)
+		assert.Equal(t, [][]string{{"To Do", "first", "second"}, {"To Do (2)"}}, boardOfArchive(imported))
+		task := imported.Columns[0].Tasks[1]
+		assert.Equal(t, "2020-12-31", *task.DueDate)
+		assert.Equal(t, []common.Id{result.Labels["l1"]}, task.LabelIds)
+		if assert.Len(t, task.Comments, 1) {
+			assert.Equal(t, "looks good", task.Comments[0].Text)
+			assert.Equal(t, 2020, task.Comments[0].CreatedAt.Year())
+		}
+		assertDelete204(t, projectPath(result.ProjectId))
+	})
+	t.Run("import Trello board with duplicate names of max length", func(t *testing.T) {
+		name := strings.Repeat("a", 250)
+		board := fmt.Sprintf(`{
+			"name": "long names",
+			"labels": [{"id": "l1", "name": %[1]q, "color": "red"}, {"id": "l2", "name": %[1]q, "color": "red"}],
+			"lists": [
+				{"id": "list1", "name": %[1]q, "closed": false, "pos": 1},
+				{"id": "list2", "name": %[1]q, "closed": false, "pos": 2}
+			],
+			"cards": [],
+			"actions": []
+		}`, name)
+		resp := sendPostRequest(t, projectsPath()+"/import/trello", json.RawMessage(board))
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusCreated)
+		result := projects.TrelloImport{}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("error while decoding import result: %v", err)
+		}
+		imported := exportProject(t, result.ProjectId)
+		// suffix is appended within length limit
+		assert.Equal(t, [][]string{{name}, {name[:246] + " (2)"}}, boardOfArchive(imported))
+		labelsNames := []string{}
+		for _, l := range imported.Labels {
+			labelsNames = append(labelsNames, l.Name)
+		}
+		assert.ElementsMatch(t, []string{name, name[:246] + " (2)"}, labelsNames)
+		assertDelete204(t, projectPath(result.ProjectId))
+	})
+}
+
+// trelloBoard contains closed list and card, which aren't imported, and lists with the same name
+const trelloBoard = `{
+	"name": "Trello board",
+	"desc": "",
+	"labels": [{"id": "l1", "name": "", "color": "green"}, {"id": "l2", "name": "green", "color": null}],
+	"lists": [
+		{"id": "list1", "name": "To Do", "closed": false, "pos": 1},
+		{"id": "list2", "name": "Archived", "closed": true, "pos": 2},
+		{"id": "list3", "name": "To Do", "closed": false, "pos": 3}
+	],
+	"cards": [
+		{"id": "c1", "name": "second", "desc": "", "idList": "list1", "closed": false, "pos": 20,
+		 "due": "2020-12-31T12:00:00.000Z", "idLabels": ["l1"]},
+		{"id": "c2", "name": "first", "desc": "", "idList": "list1", "closed": false, "pos": 10, "due": null},
+		{"id": "c3", "name": "closed", "desc": "", "idList": "list3", "closed": true, "pos": 1, "due": null}
+	],
+	"actions": [
+		{"id": "a1", "type": "commentCard", "date": "2020-11-01T10:00:00.000Z",
+		 "data": {"text": "looks good", "card": {"id": "c1"}}},
+		{"id": "a2", "type": "updateCard", "date": "2020-11-02T10:00:00.000Z", "data": {"card": {"id": "c1"}}}
+	]
+}`
+
+func exportProject(t *testing.T, projectId common.Id) common.ProjectArchive {
+	resp := sendGetRequest(t, projectPath(projectId)+"/export")
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	archive := common.ProjectArchive{}
+	if err := json.NewDecoder(resp.Body).Decode(&archive); err != nil {
+		t.Fatalf("error while decoding archive: %v", err)
+	}
+	return archive
+}
+
+// boardOfProject returns names of columns followed by names of their tasks
+func boardOfProject(p common.ProjectExpanded) [][]string {
+	board := [][]string{}
+	for _, c := range p.Columns {
+		column := []string{c.Name}
+		for _, t := range c.Tasks {
+			column = append(column, t.Name)
+		}
+		board = append(board, column)
+	}
+	return board
+}
+
+// boardOfArchive is boardOfProject for archive
+func boardOfArchive(a common.ProjectArchive) [][]string {
+	board := [][]string{}
+	for _, c := range a.Columns {
+		column := []string{c.Name}
+		for _, t := range c.Tasks {
+			column = append(column, t.Name)
+		}
+		board = append(board, column)
+	}
+	return board
 }
 
 func runSubtestsDelete(t *testing.T) {
@@ -310,13 +1245,85 @@ func runSubtestsDelete(t *testing.T) {
 		assertDelete204(t, path)
 		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
 	})
+	t.Run("rebalance project keeps order", func(t *testing.T) {
+		assertPost(t, projectPath(project3.Id)+"/rebalance", http.StatusNoContent)
+		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
+	})
+	attachmentPath := ""
 	t.Run("delete task", func(t *testing.T) {
+		resp := uploadFile(t, taskPath(task3.Id)+"/attachments", "a.txt", []byte("text"))
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusCreated)
+		attachmentPath = resp.Header.Get("Location")
+		stored, err := db.Query().Attachments().GetMultiple(context.Background(), task3.Id)
+		if err != nil || len(stored) != 1 {
+			t.Fatalf("cannot get stored attachments: %v", err)
+		}
 		assertDelete204(t, taskPath(task3.Id))
 		assertGet404(t, taskPath(task3.Id))
+		// attachments stay with task in trash until it's purged
+		_, err = storage.Blobs.Get(context.Background(), stored[0].StorageKey)
+		assert.NoError(t, err)
+	})
+	t.Run("list trash", func(t *testing.T) {
+		items := getTrash(t, trashPath()+"?project_id="+idToStr(project3.Id))
+		// comments of trashed task aren't listed
+		if !assert.Len(t, items, 3) {
+			t.FailNow()
+		}
+		assert.Equal(t, "task", items[0].Type)
+		assert.Equal(t, task3.Id, items[0].Id)
+		assert.Equal(t, task3.Name, items[0].Name)
+		assert.Equal(t, column4P3.Id, items[1].Id)
+		assert.Equal(t, column3P3Def.Id, items[2].Id)
+	})
+	t.Run("restore task with comments and attachments deleted along with it", func(t *testing.T) {
+		assertPost(t, restorePath(taskPath(task3.Id)), http.StatusNoContent)
+		assertGet200(t, taskPath(task3.Id), task3.Task)
+		assertGet200(t, commentsPath(task3.Id), []common.Comment{comment2T3})
+		assertPost(t, restorePath(taskPath(task3.Id)), http.StatusNotFound)
+		resp := sendGetRequest(t, strings.TrimPrefix(attachmentPath, api.BasePath))
+		defer resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusOK)
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("error while reading response body: %v", err)
+		}
+		assert.Equal(t, []byte("text"), content)
+		// comment deleted before task stays in trash
+		items := getTrash(t, trashPath()+"?project_id="+idToStr(project3.Id))
+		if assert.Len(t, items, 3) {
+			assert.Equal(t, "comment", items[2].Type)
+			assert.Equal(t, comment1T3.Id, items[2].Id)
+			assert.Equal(t, task3.Id, *items[2].TaskId)
+		}
+	})
+	t.Run("restore column", func(t *testing.T) {
+		assertPost(t, restorePath(columnPath(project3.Id, column4P3.Id)), http.StatusNoContent)
+		column4P3.Tasks = []common.Task{}
+		project3.Columns = []common.ColumnExpanded{column4P3, column5P3}
+		assertGet200(t, projectPath(project3.Id)+"?expanded", project3)
+	})
+	t.Run("cannot restore column with duplicate name", func(t *testing.T) {
+		column := common.ColumnSettableFields{Name: column3P3Def.Name}
+		assertPatch(t, columnPath(project3.Id, column4P3.Id), column, http.StatusNoContent)
+		assertPost(t, restorePath(columnPath(project3.Id, column3P3Def.Id)), http.StatusConflict)
 	})
 	t.Run("delete project", func(t *testing.T) {
 		assertDelete204(t, projectPath(project3.Id))
-		assertGet200(t, projectsPath(), []common.Project{project2.Project, project1.Project})
+		projects := []common.Project{project2.Project, project1.Project}
+		assertGet200(t, projectsPath(), common.ProjectsPage{Projects: projects})
+		assertGet404(t, taskPath(task3.Id))
+	})
+	t.Run("restore project", func(t *testing.T) {
+		items := getTrash(t, trashPath()+"?limit=1")
+		if assert.Len(t, items, 1) {
+			assert.Equal(t, "project", items[0].Type)
+			assert.Equal(t, project3.Id, items[0].Id)
+		}
+		assertPost(t, restorePath(projectPath(project3.Id)), http.StatusNoContent)
+		assertGet200(t, commentsPath(task3.Id), []common.Comment{comment2T3})
+		assertDelete204(t, projectPath(project3.Id))
 	})
 }
 
@@ -333,6 +1340,51 @@ func assertGet404(t *testing.T, path string) {
 	assertEqualStatusCode(t, resp, http.StatusNotFound)
 }
 
+func assertGet400(t *testing.T, path string) {
+	resp := sendGetRequest(t, path)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusBadRequest)
+}
+
+func assertGet422(t *testing.T, path string) {
+	resp := sendGetRequest(t, path)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusUnprocessableEntity)
+}
+
+func getProjectsPage(t *testing.T, path string) common.ProjectsPage {
+	resp := sendGetRequest(t, path)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	page := common.ProjectsPage{}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("error while decoding projects page: %v", err)
+	}
+	return page
+}
+
+func getTrash(t *testing.T, path string) []common.TrashItem {
+	resp := sendGetRequest(t, path)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	items := []common.TrashItem{}
+	if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+		t.Fatalf("error while decoding trash: %v", err)
+	}
+	return items
+}
+
+func getActivityPage(t *testing.T, path string) common.ActivityPage {
+	resp := sendGetRequest(t, path)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	page := common.ActivityPage{}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("error while decoding activity page: %v", err)
+	}
+	return page
+}
+
 func assertPost201(t *testing.T, path string, reqBody interface{}, wantResource interface{}) {
 	resp := sendPostRequest(t, path, reqBody)
 	defer resp.Body.Close()
@@ -345,17 +1397,69 @@ func assertPost201(t *testing.T, path string, reqBody interface{}, wantResource
 	assertGet200(t, location, wantResource)
 }
 
+// assertPostComment returns created comment with its author and timestamps
+func assertPostComment(t *testing.T, taskId common.Id, want common.Comment) common.Comment {
+	got := common.Comment{}
+	postResource(t, commentsPath(taskId), want.CommentSettableFields, &got)
+	assert.Equal(t, want.Id, got.Id)
+	assert.Equal(t, want.Text, got.Text)
+	assert.Equal(t, owner.Id, got.AuthorId)
+	assert.False(t, got.CreatedAt.IsZero())
+	assert.True(t, got.UpdatedAt.Equal(got.CreatedAt))
+	assertGet200(t, commentPath(taskId, got.Id), got)
+	return got
+}
+
+func getComment(t *testing.T, taskId, commentId common.Id) common.Comment {
+	resp := sendGetRequest(t, commentPath(taskId, commentId))
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	comment := common.Comment{}
+	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+		t.Fatalf("error while decoding response body: %v", err)
+	}
+	return comment
+}
+
+// postResource creates resource and decodes it into dst
+func postResource(t *testing.T, path string, reqBody interface{}, dst interface{}) {
+	resp := sendPostRequest(t, path, reqBody)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusCreated)
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		t.Fatalf("error while decoding created resource: %v", err)
+	}
+}
+
 func assertPost409(t *testing.T, path string, reqBody interface{}) {
 	resp := sendPostRequest(t, path, reqBody)
 	defer resp.Body.Close()
 	assertEqualStatusCode(t, resp, http.StatusConflict)
 }
 
+func assertPost422(t *testing.T, path string, reqBody interface{}) {
+	resp := sendPostRequest(t, path, reqBody)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusUnprocessableEntity)
+}
+
+func assertPost(t *testing.T, path string, wantCode int) {
+	resp := sendPostRequest(t, path, nil)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, wantCode)
+}
+
 func assertPut204(t *testing.T, path string, body interface{}) {
 	resp := sendPutRequest(t, path, body)
 	assertEqualStatusCode(t, resp, http.StatusNoContent)
 }
 
+func assertPatch(t *testing.T, path string, body interface{}, wantCode int) {
+	resp := sendRequest(t, "PATCH", path, body)
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, wantCode)
+}
+
 func assertPut409(t *testing.T, path string, body interface{}) {
 	resp := sendPutRequest(t, path, body)
 	assertEqualStatusCode(t, resp, http.StatusConflict)
@@ -399,6 +1503,20 @@ func sendDeleteRequest(t *testing.T, path string) *http.Response {
 }
 
 func sendRequest(t *testing.T, method, path string, body interface{}) *http.Response {
+	return sendRequestWithToken(t, token, method, path, body)
+}
+
+func sendRequestWithToken(t *testing.T, token, method, path string, body interface{}) *http.Response {
+	return doRequest(t, newRequest(t, token, method, path, body))
+}
+
+func sendRequestWithHeader(t *testing.T, method, path, header, value string, body interface{}) *http.Response {
+	req := newRequest(t, token, method, path, body)
+	req.Header.Set(header, value)
+	return doRequest(t, req)
+}
+
+func newRequest(t *testing.T, token, method, path string, body interface{}) *http.Request {
 	var reqBody io.Reader = nil
 	if body != nil {
 		bodyBytes, err := json.Marshal(body)
@@ -411,6 +1529,13 @@ func sendRequest(t *testing.T, method, path string, body interface{}) *http.Resp
 	if err != nil {
 		t.Fatalf("new request failed: %v", err)
 	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func doRequest(t *testing.T, req *http.Request) *http.Response {
 	resp, err := client.Do(req)
 	if err != nil {
 		t.Fatalf("error while sending request: %v", err)
@@ -418,6 +1543,74 @@ func sendRequest(t *testing.T, method, path string, body interface{}) *http.Resp
 	return resp
 }
 
+// createUserAndToken is used outside of tests, so it terminates program on failure
+func createUserAndToken(user common.User) string {
+	credentials := common.Credentials{Name: user.Name, Password: password}
+	gotUser := common.User{}
+	if code := postJSON(usersPath(), credentials, &gotUser); code != http.StatusCreated || gotUser != user {
+		log.Fatalf("cannot create user %v, status code %v", user, code)
+	}
+	t := common.Token{}
+	if code := postJSON(tokensPath(), credentials, &t); code != http.StatusCreated {
+		log.Fatalf("cannot create token for user %v, status code %v", user, code)
+	}
+	return t.Token
+}
+
+func postJSON(path string, body interface{}, respBody interface{}) int {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		log.Fatalf("error while marshaling request body: %v", err)
+	}
+	resp, err := client.Post(URL+api.BasePath+path, "application/json", bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		log.Fatalf("error while sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusCreated {
+		if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+			log.Fatalf("error while decoding response body: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func usersPath() string {
+	return "/users"
+}
+
+func userPath(userId common.Id) string {
+	return "/users/" + idToStr(userId)
+}
+
+func tokensPath() string {
+	return "/tokens"
+}
+
+func membersPath(projectId common.Id) string {
+	return "/projects/" + idToStr(projectId) + "/members"
+}
+
+func memberPath(projectId, userId common.Id) string {
+	return "/projects/" + idToStr(projectId) + "/members/" + idToStr(userId)
+}
+
+func searchPath() string {
+	return "/search"
+}
+
+func checklistPath(checklistId common.Id) string {
+	return "/checklists/" + idToStr(checklistId)
+}
+
+func trashPath() string {
+	return "/trash"
+}
+
+func restorePath(resourcePath string) string {
+	return resourcePath + "/restore"
+}
+
 func projectsPath() string {
 	return "/projects"
 }
@@ -426,6 +1619,14 @@ func projectPath(projectId common.Id) string {
 	return "/projects/" + idToStr(projectId)
 }
 
+func projectActivityPath(projectId common.Id) string {
+	return projectPath(projectId) + "/activity"
+}
+
+func projectEventsPath(projectId common.Id) string {
+	return projectPath(projectId) + "/events"
+}
+
 func columnsPath(projectId common.Id) string {
 	return "/projects/" + idToStr(projectId) + "/columns"
 }
@@ -446,10 +1647,34 @@ func taskPath(taskId common.Id) string {
 	return "/tasks/" + idToStr(taskId)
 }
 
+func tasksBatchPath() string {
+	return "/tasks/batch"
+}
+
+func taskActivityPath(taskId common.Id) string {
+	return taskPath(taskId) + "/activity"
+}
+
 func taskPositionPath(taskId common.Id) string {
 	return "/tasks/" + idToStr(taskId) + "/position"
 }
 
+func labelsPath(projectId common.Id) string {
+	return "/projects/" + idToStr(projectId) + "/labels"
+}
+
+func labelPath(projectId, labelId common.Id) string {
+	return "/projects/" + idToStr(projectId) + "/labels/" + idToStr(labelId)
+}
+
+func taskLabelsPath(taskId common.Id) string {
+	return "/tasks/" + idToStr(taskId) + "/labels"
+}
+
+func taskLabelPath(taskId, labelId common.Id) string {
+	return "/tasks/" + idToStr(taskId) + "/labels/" + idToStr(labelId)
+}
+
 func commentsPath(taskId common.Id) string {
 	return "/tasks/" + idToStr(taskId) + "/comments"
 }
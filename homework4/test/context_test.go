@@ -0,0 +1,32 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_CanceledContextAbortsQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := db.Query().Projects().Get(ctx, 1)
+	assert.True(t, errors.Is(err, context.Canceled), "unexpected error %v", err)
+}
+
+func Test_DeadlineAbortsTransaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("cannot begin transaction: %v", err)
+	}
+	defer db.Rollback(ctx, tx)
+	start := time.Now()
+	_, err = tx.Exec(ctx, "SELECT pg_sleep(5)")
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(2*time.Second))
+	assert.Error(t, db.Commit(ctx, tx))
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_EventsCommittedOutOfIdOrder(t *testing.T) {
+	project := common.Project{}
+	postResource(t, projectsPath(), common.ProjectSettableFields{Name: "events order"}, &project)
+	stream := openEventStream(t, projectEventsPath(project.Id), 0)
+
+	ctx := context.Background()
+	first, firstId := beginActivity(t, ctx, project.Id)
+	defer db.Rollback(ctx, first)
+	second, secondId := beginActivity(t, ctx, project.Id)
+	defer db.Rollback(ctx, second)
+	if !assert.Less(t, int(firstId), int(secondId)) {
+		t.FailNow()
+	}
+	// activity with greater id is committed first
+	if err := db.Commit(ctx, second); err != nil {
+		t.Fatalf("cannot commit transaction: %v", err)
+	}
+	if err := db.Commit(ctx, first); err != nil {
+		t.Fatalf("cannot commit transaction: %v", err)
+	}
+
+	t.Run("stream delivers events in commit order", func(t *testing.T) {
+		secondEvent := readUpdateEvent(t, stream)
+		firstEvent := readUpdateEvent(t, stream)
+		assert.Equal(t, secondId, secondEvent.activity.Id)
+		assert.Equal(t, firstId, firstEvent.activity.Id)
+		assert.Less(t, int(secondEvent.id), int(firstEvent.id))
+
+		t.Run("reconnected stream replays event with lower id committed later", func(t *testing.T) {
+			replayed := openEventStream(t, projectEventsPath(project.Id), secondEvent.id)
+			assert.Equal(t, firstEvent, readEvent(t, replayed))
+		})
+	})
+}
+
+// readUpdateEvent skips notification of project creation, which may arrive after stream is opened
+func readUpdateEvent(t *testing.T, stream *bufio.Reader) sseEvent {
+	for {
+		if event := readEvent(t, stream); event.activity.Action == common.ActionUpdate {
+			return event
+		}
+	}
+}
+
+// beginActivity inserts activity of project in new transaction, which is left uncommitted
+func beginActivity(t *testing.T, ctx context.Context, projectId common.Id) (db.TX, common.Id) {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatalf("cannot begin transaction: %v", err)
+	}
+	id, err := db.QueryWithTX(tx).Activity().Create(ctx, common.Activity{
+		ProjectId:    projectId,
+		ActorId:      owner.Id,
+		ResourceType: common.ResourceProject,
+		ResourceId:   projectId,
+		Action:       common.ActionUpdate,
+		Diff:         common.ActivityDiff{Before: json.RawMessage(`{}`), After: json.RawMessage(`{}`)},
+	})
+	if err != nil {
+		db.Rollback(ctx, tx)
+		t.Fatalf("cannot create activity: %v", err)
+	}
+	return tx, id
+}
@@ -0,0 +1,24 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_RequestId(t *testing.T) {
+	t.Run("request id is propagated", func(t *testing.T) {
+		resp := sendRequestWithHeader(t, "GET", projectsPath(), "X-Request-ID", "abc-123", nil)
+		resp.Body.Close()
+		assert.Equal(t, "abc-123", resp.Header.Get("X-Request-ID"))
+	})
+	t.Run("request id is generated", func(t *testing.T) {
+		resp := sendGetRequest(t, projectsPath())
+		resp.Body.Close()
+		assert.Regexp(t, "^[0-9a-f]{32}$", resp.Header.Get("X-Request-ID"))
+	})
+	t.Run("invalid request id is replaced", func(t *testing.T) {
+		resp := sendRequestWithHeader(t, "GET", projectsPath(), "X-Request-ID", "a b\"c", nil)
+		resp.Body.Close()
+		assert.Regexp(t, "^[0-9a-f]{32}$", resp.Header.Get("X-Request-ID"))
+	})
+}
@@ -0,0 +1,30 @@
+package test
+
+import (
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func Test_Metrics(t *testing.T) {
+	sendGetRequest(t, projectPath(nonExistentId)).Body.Close()
+	common.CalculateRankBetween("b", "c")
+	resp, err := client.Get(URL + "/metrics")
+	if err != nil {
+		t.Fatalf("error while sending request: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error while reading response body: %v", err)
+	}
+	// route pattern is used instead of path with id
+	assert.Contains(t, string(body), `gorello_http_requests_total{method="GET",route="/api/v1/projects/{projectID:`)
+	assert.NotContains(t, string(body), `route="/api/v1/projects/9999`)
+	assert.Contains(t, string(body), "gorello_db_pool_max_connections")
+	assert.Contains(t, string(body), "gorello_db_transaction_rollbacks_total")
+	assert.Contains(t, string(body), "gorello_rank_length_bucket")
+}
@@ -28,6 +28,26 @@ func Test_CalculateRank(t *testing.T) {
 	}
 }
 
+func Test_CalculateRanksEvenly(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 12, 13, 1000, 100000} {
+		ranks := common.CalculateRanksEvenly(n)
+		if len(ranks) != n {
+			t.Fatalf("CalculateRanksEvenly(%v) returned %v ranks", n, len(ranks))
+		}
+		for i, rank := range ranks {
+			if !isValidRank(rank) || len(rank) != len(ranks[0]) {
+				t.Errorf("CalculateRanksEvenly(%v) returned incorrect rank %v", n, rank)
+			}
+			if i > 0 && ranks[i-1] >= rank {
+				t.Errorf("CalculateRanksEvenly(%v) returned unordered ranks %v, %v", n, ranks[i-1], rank)
+			}
+		}
+		if n > 0 && len(ranks[0]) > 4 {
+			t.Errorf("CalculateRanksEvenly(%v) returned too long ranks %v", n, ranks[0])
+		}
+	}
+}
+
 func randRanksPair() (common.Rank, common.Rank) {
 	var a, b common.Rank
 	a = randRank()
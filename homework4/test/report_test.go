@@ -0,0 +1,35 @@
+package test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func Test_TasksReportEscapesFormulas(t *testing.T) {
+	project := common.Project{}
+	postResource(t, projectsPath(), common.ProjectSettableFields{Name: "report"}, &project)
+	expanded := common.ProjectExpanded{}
+	resp := sendGetRequest(t, projectPath(project.Id)+"?expanded")
+	err := json.NewDecoder(resp.Body).Decode(&expanded)
+	resp.Body.Close()
+	if err != nil || len(expanded.Columns) == 0 {
+		t.Fatalf("cannot get project columns: %v", err)
+	}
+	fields := common.TaskSettableFields{Name: `=HYPERLINK("http://example.com")`, Description: "-1+2"}
+	postResource(t, tasksPath(project.Id, expanded.Columns[0].Id), fields, &common.Task{})
+
+	resp = sendGetRequest(t, projectPath(project.Id)+"/tasks.csv")
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("cannot read report: %v", err)
+	}
+	assert.Equal(t, `'=HYPERLINK("http://example.com")`, records[1][3])
+	assert.Equal(t, "'-1+2", records[1][4])
+	assert.Equal(t, common.DefaultColumnName, records[1][1])
+}
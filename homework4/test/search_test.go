@@ -0,0 +1,37 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func Test_SearchEscapesSnippet(t *testing.T) {
+	project := common.Project{}
+	postResource(t, projectsPath(), common.ProjectSettableFields{Name: "search"}, &project)
+	expanded := common.ProjectExpanded{}
+	resp := sendGetRequest(t, projectPath(project.Id)+"?expanded")
+	err := json.NewDecoder(resp.Body).Decode(&expanded)
+	resp.Body.Close()
+	if err != nil || len(expanded.Columns) == 0 {
+		t.Fatalf("cannot get project columns: %v", err)
+	}
+	fields := common.TaskSettableFields{
+		Name:        "<script>alert(1)</script>",
+		Description: `<img src=x onerror="alert(1)"> xsscheck`,
+	}
+	postResource(t, tasksPath(project.Id, expanded.Columns[0].Id), fields, &common.Task{})
+
+	resp = sendGetRequest(t, searchPath()+"?q=xsscheck&project_id="+idToStr(project.Id))
+	defer resp.Body.Close()
+	assertEqualStatusCode(t, resp, http.StatusOK)
+	hits := []common.SearchHit{}
+	if err := json.NewDecoder(resp.Body).Decode(&hits); err != nil || len(hits) != 1 {
+		t.Fatalf("cannot get search hits: %v", err)
+	}
+	assert.Contains(t, hits[0].Snippet, "<b>xsscheck</b>")
+	assert.NotContains(t, hits[0].Snippet, "<script")
+	assert.NotContains(t, hits[0].Snippet, "<img")
+}
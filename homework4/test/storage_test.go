@@ -0,0 +1,85 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/storage"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func Test_LocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatalf("cannot create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	local, err := storage.NewLocal(dir)
+	if err != nil {
+		t.Fatalf("cannot create local storage: %v", err)
+	}
+	testStorage(t, local)
+	t.Run("key cannot escape storage dir", func(t *testing.T) {
+		assert.Error(t, local.Put(context.Background(), "../escaped", bytes.NewReader(nil), 0, "text/plain"))
+	})
+}
+
+// Test_S3Storage runs against S3-compatible service, e.g. MinIO from docker-compose.test.yml,
+// bucket must exist
+func Test_S3Storage(t *testing.T) {
+	endpoint := os.Getenv("S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_ENDPOINT is not set")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		t.Fatalf("invalid S3_ENDPOINT: %v", err)
+	}
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+	s3 := storage.NewS3(u, os.Getenv("S3_BUCKET"), region, os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"))
+	testStorage(t, s3)
+	t.Run("canceled context aborts request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := s3.Get(ctx, "tasks/1/blob")
+		assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+	})
+}
+
+func testStorage(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	key := "tasks/1/blob"
+	content := []byte("content")
+	t.Run("put and get", func(t *testing.T) {
+		if err := s.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+			t.Fatalf("put failed: %v", err)
+		}
+		r, err := s.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		defer r.Close()
+		got, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, content, got)
+	})
+	t.Run("put empty blob", func(t *testing.T) {
+		assert.NoError(t, s.Put(ctx, "tasks/1/empty", bytes.NewReader(nil), 0, "text/plain"))
+		assert.NoError(t, s.Delete(ctx, "tasks/1/empty"))
+	})
+	t.Run("delete", func(t *testing.T) {
+		assert.NoError(t, s.Delete(ctx, key))
+		_, err := s.Get(ctx, key)
+		assert.Equal(t, storage.ErrNotFound, err)
+	})
+	t.Run("delete missing blob", func(t *testing.T) {
+		assert.NoError(t, s.Delete(ctx, key))
+	})
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/api"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/db"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources"
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/common"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+var spanRecorder = tracetest.NewSpanRecorder()
+
+var setTracerProvider sync.Once
+
+// startTrace starts root span of test, spans of all tests are recorded, so they are told apart by trace id
+func startTrace(t *testing.T) (context.Context, trace.Span) {
+	setTracerProvider.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	return otel.Tracer("test").Start(context.Background(), t.Name())
+}
+
+// endedSpans returns ended spans of trace except root one
+func endedSpans(root trace.Span) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range spanRecorder.Ended() {
+		sc := span.SpanContext()
+		if sc.TraceID() == root.SpanContext().TraceID() && sc.SpanID() != root.SpanContext().SpanID() {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// findSpan waits for span, since server span may end after client reads response
+func findSpan(t *testing.T, root trace.Span, name string) sdktrace.ReadOnlySpan {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		for _, span := range endedSpans(root) {
+			if span.Name() == name {
+				return span
+			}
+		}
+	}
+	t.Fatalf("span %q is not ended", name)
+	return nil
+}
+
+func Test_TracingStatementSpans(t *testing.T) {
+	t.Run("rows span ends when rows are read", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		rows, err := db.Query().Q.Query(ctx, "SELECT generate_series(1, 2)")
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		defer rows.Close()
+		rows.Next()
+		assert.Empty(t, endedSpans(root))
+		for rows.Next() {
+		}
+		span := findSpan(t, root, "SQL SELECT")
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+	t.Run("rows span ends when rows are closed", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		rows, err := db.Query().Q.Query(ctx, "SELECT generate_series(1, 2)")
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		rows.Next()
+		rows.Close()
+		assert.Len(t, endedSpans(root), 1)
+		rows.Close()
+		assert.Len(t, endedSpans(root), 1, "span is ended twice")
+	})
+	t.Run("row span ends on scan without failure if there is no row", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		row := db.Query().Q.QueryRow(ctx, "SELECT 1 WHERE false")
+		assert.Empty(t, endedSpans(root))
+		var n int
+		assert.True(t, errors.Is(row.Scan(&n), pgx.ErrNoRows))
+		span := findSpan(t, root, "SQL SELECT")
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+	t.Run("row span is failed if statement fails", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		var n int
+		assert.Error(t, db.Query().Q.QueryRow(ctx, "SELECT 1 / 0").Scan(&n))
+		span := findSpan(t, root, "SQL SELECT")
+		assert.Equal(t, codes.Error, span.Status().Code)
+	})
+}
+
+type tracedRequest struct {
+	err error
+}
+
+func (r tracedRequest) Handle(ctx context.Context) (interface{}, error) {
+	return nil, r.err
+}
+
+func Test_TracingRequestSpans(t *testing.T) {
+	t.Run("span is named by request type", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		_, _ = resources.Handle(ctx, &tracedRequest{})
+		_, _ = resources.Handle(ctx, tracedRequest{})
+		spans := endedSpans(root)
+		if assert.Len(t, spans, 2) {
+			assert.Equal(t, "test.tracedRequest", spans[0].Name())
+			assert.Equal(t, "test.tracedRequest", spans[1].Name())
+		}
+	})
+	t.Run("only internal errors mark span as failed", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		_, _ = resources.Handle(ctx, tracedRequest{err: common.NewNotFountError()})
+		_, _ = resources.Handle(ctx, tracedRequest{err: common.NewInternalError("failed", errors.New("cause"))})
+		spans := endedSpans(root)
+		if assert.Len(t, spans, 2) {
+			assert.Equal(t, codes.Unset, spans[0].Status().Code)
+			assert.Equal(t, codes.Error, spans[1].Status().Code)
+		}
+	})
+	t.Run("http span is named by route pattern", func(t *testing.T) {
+		ctx, root := startTrace(t)
+		defer root.End()
+		req := newRequest(t, token, "GET", projectPath(nonExistentId), nil)
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+		resp := doRequest(t, req)
+		resp.Body.Close()
+		assertEqualStatusCode(t, resp, http.StatusNotFound)
+		span := findSpan(t, root, "GET "+api.BasePath+"/projects/{projectID:[\\d]+}/")
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, codes.Unset, span.Status().Code)
+	})
+}
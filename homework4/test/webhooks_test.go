@@ -0,0 +1,30 @@
+package test
+
+import (
+	"github.com/AndreyKlimchuk/golang-learning/homework4/resources/webhooks"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func Test_WebhookAddresses(t *testing.T) {
+	for address, public := range map[string]bool{
+		"93.184.216.34":      true,
+		"2606:2800:220:1::1": true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"100.64.0.1":         false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"224.0.0.1":          false,
+		"0.0.0.0":            false,
+		"::":                 false,
+		"::ffff:10.0.0.1":    false,
+	} {
+		assert.Equal(t, public, webhooks.IsPublicAddress(net.ParseIP(address)), address)
+	}
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "gorello"
+
+// Tracer delegates to provider set by Init, so it can be used before initialization
+var Tracer = otel.Tracer("github.com/AndreyKlimchuk/golang-learning/homework4")
+
+var provider *sdktrace.TracerProvider
+
+// Init sets exporter of spans from TRACES_EXPORTER env variable: stdout, otlp or none, which is default.
+// OTLP exporter sends spans over HTTP and is configured by standard OTEL_EXPORTER_OTLP_* env variables.
+func Init() error {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch name := os.Getenv("TRACES_EXPORTER"); name {
+	case "", "none":
+		return nil
+	case "stdout":
+		exporter, err = stdouttrace.New()
+	case "otlp":
+		exporter, err = otlptracehttp.New(context.Background())
+	default:
+		return fmt.Errorf("unknown traces exporter %q", name)
+	}
+	if err != nil {
+		return err
+	}
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	// trace is continued from traceparent header of incoming request
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return nil
+}
+
+// Shutdown exports remaining spans, it does nothing if tracing is disabled
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// End ends span and marks it as failed if err is not nil
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}